@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/invoice"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/storage"
+)
+
+// CustomerData is everything held about a customer, assembled for a data subject access request.
+// Omitted lists what the export leaves out, including data no longer held.
+type CustomerData struct {
+	Customer        customer.Customer
+	Invoices        []invoice.Invoice
+	Verifications   []customer.Verification
+	StatementEmails []customer.StatementEmail
+	Erasures        []customer.Erasure
+	Files           []ExportFile
+	Omitted         []ExportOmission
+	ExportedAt      time.Time
+}
+
+// ExportOmission names data a customer export leaves out and why.
+type ExportOmission struct {
+	Data   string
+	Reason string
+}
+
+type ExportFile struct {
+	Name string
+	Data []byte
+}
+
+type ExportCustomerData struct {
+	custSvc *customer.Service
+	invSvc  *invoice.Service
+	storage storage.Storage
+	logger  logger.Logger
+}
+
+func NewExportCustomerData(
+	custSvc *customer.Service,
+	invSvc *invoice.Service,
+	storage storage.Manager,
+	logger logger.Logger,
+) *ExportCustomerData {
+	return &ExportCustomerData{
+		custSvc: custSvc,
+		invSvc:  invSvc,
+		storage: storage,
+		logger:  logger.With("component", "app.export_customer_data"),
+	}
+}
+
+func (e *ExportCustomerData) Execute(ctx context.Context, customerID uuid.UUID) (*CustomerData, error) {
+	cust, err := e.custSvc.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+
+	invs, err := e.invSvc.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list invoices: %w", err)
+	}
+
+	vers, err := e.custSvc.ListVerifications(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list verifications: %w", err)
+	}
+
+	stmts, err := e.custSvc.ListStatementEmails(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list statement emails: %w", err)
+	}
+
+	erasures, err := e.custSvc.ListErasures(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list erasures: %w", err)
+	}
+
+	omitted := []ExportOmission{
+		{Data: "email contents", Reason: "sent emails are not stored; verification and statement emails are listed by when they were sent"},
+		{Data: "application logs", Reason: "logs may mention the customer ID but are kept outside the database and cannot be searched per customer"},
+		{Data: "welcome email", Reason: "sent when the customer was created; its sending is not recorded"},
+		{Data: "payments", Reason: "not recorded separately; a paid invoice carries the date it was paid"},
+		{Data: "audit entries", Reason: "no audit log of changes to customers is kept"},
+	}
+
+	if cust.IsErased() {
+		omitted = append(omitted,
+			ExportOmission{Data: "personal details", Reason: "name, email, avatar and custom fields were anonymised when the customer was erased"},
+			ExportOmission{Data: "verification emails", Reason: "deleted when the customer was erased"},
+		)
+	}
+
+	var files []ExportFile
+
+	if cust.ImageURL != nil {
+		for _, size := range AvatarSizes {
+			data, err := e.readFile(ctx, avatarKey(customerID, size))
+			if err != nil {
+				if errors.Is(err, storage.ErrObjectNotFound) {
+					omitted = append(omitted, ExportOmission{Data: fmt.Sprintf("avatar_%d.png", size), Reason: "not found in storage"})
+					continue
+				}
+				return nil, fmt.Errorf("read avatar: %w", err)
+			}
+
+			files = append(files, ExportFile{
+				Name: fmt.Sprintf("avatar_%d.png", size),
+				Data: data,
+			})
+		}
+	}
+
+	e.logger.InfoContext(ctx, "customer data exported", "customer_id", customerID)
+
+	return &CustomerData{
+		Customer:        *cust,
+		Invoices:        invs,
+		Verifications:   vers,
+		StatementEmails: stmts,
+		Erasures:        erasures,
+		Files:           files,
+		Omitted:         omitted,
+		ExportedAt:      time.Now(),
+	}, nil
+}
+
+func (e *ExportCustomerData) readFile(ctx context.Context, key string) ([]byte, error) {
+	rc, err := e.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+type EraseCustomer struct {
+	custSvc *customer.Service
+	storage storage.Storage
+	txm     db.TxManager
+	logger  logger.Logger
+}
+
+func NewEraseCustomer(
+	custSvc *customer.Service,
+	storage storage.Manager,
+	txm db.TxManager,
+	logger logger.Logger,
+) *EraseCustomer {
+	return &EraseCustomer{
+		custSvc: custSvc,
+		storage: storage,
+		txm:     txm,
+		logger:  logger.With("component", "app.erase_customer"),
+	}
+}
+
+// Execute anonymises the customer and removes their stored files. Invoices keep
+// their amounts, dates and customer reference for legal retention.
+func (e *EraseCustomer) Execute(ctx context.Context, customerID, requestedBy uuid.UUID, reason string) (*customer.Erasure, error) {
+	var erasure *customer.Erasure
+
+	txErr := e.txm.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		if erasure, err = e.custSvc.Erase(txCtx, customerID, requestedBy, reason); err != nil {
+			return fmt.Errorf("erase customer: %w", err)
+		}
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, fmt.Errorf("erase customer tx: %w", txErr)
+	}
+
+	// the personal data is already gone from the database; a leftover file is logged, not fatal
+	for _, size := range AvatarSizes {
+		if err := e.storage.Delete(ctx, avatarKey(customerID, size)); err != nil {
+			e.logger.ErrorContext(ctx, "failed to delete avatar", "customer_id", customerID, "error", err.Error())
+		}
+	}
+
+	return erasure, nil
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/invoice"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/mail"
+	"github.com/gelozr/go-dash/internal/statement"
+)
+
+type CustomerStatement struct {
+	cfg     *config.Config
+	custSvc *customer.Service
+	invSvc  *invoice.Service
+	mailer  mail.Mailer
+	logger  logger.Logger
+}
+
+func NewCustomerStatement(
+	cfg *config.Config,
+	custSvc *customer.Service,
+	invSvc *invoice.Service,
+	mailer mail.Mailer,
+	logger logger.Logger,
+) *CustomerStatement {
+	return &CustomerStatement{
+		cfg:     cfg,
+		custSvc: custSvc,
+		invSvc:  invSvc,
+		mailer:  mailer,
+		logger:  logger.With("component", "app.customer_statement"),
+	}
+}
+
+func (c *CustomerStatement) Execute(ctx context.Context, customerID uuid.UUID, from, to time.Time) (*statement.Statement, error) {
+	cust, err := c.custSvc.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+
+	invs, err := c.invSvc.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list invoices: %w", err)
+	}
+
+	s := statement.Build(*cust, invs, from, to)
+
+	return &s, nil
+}
+
+// Email sends the statement to the customer's email address.
+func (c *CustomerStatement) Email(ctx context.Context, customerID uuid.UUID, from, to time.Time) (*statement.Statement, error) {
+	s, err := c.Execute(ctx, customerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Customer.IsErased() {
+		return nil, customer.ErrCustomerErased
+	}
+
+	var html, text strings.Builder
+
+	if err = statement.RenderHTML(&html, *s); err != nil {
+		return nil, fmt.Errorf("render html: %w", err)
+	}
+	if err = statement.RenderText(&text, *s); err != nil {
+		return nil, fmt.Errorf("render text: %w", err)
+	}
+
+	m := &mail.Message{
+		From: mail.Address{
+			Name:    c.cfg.MailFromName,
+			Address: c.cfg.MailFromAddress,
+		},
+		To: []mail.Address{{
+			Name:    s.Customer.Name,
+			Address: s.Customer.Email,
+		}},
+		Subject: fmt.Sprintf("Statement of account %s to %s", from.Format(time.DateOnly), to.Format(time.DateOnly)),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}
+
+	if err = c.mailer.Send(ctx, m); err != nil {
+		return nil, fmt.Errorf("send statement: %w", err)
+	}
+
+	c.logger.InfoContext(ctx, "statement emailed", "customer_id", customerID)
+
+	// the email is already sent; failing would only invite a second one
+	if _, err = c.custSvc.RecordStatementEmail(ctx, customerID, from, to); err != nil {
+		c.logger.ErrorContext(ctx, "failed to record statement email", "customer_id", customerID, "error", err.Error())
+	}
+
+	return s, nil
+}
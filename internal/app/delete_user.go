@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/auth"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/tenant"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type DeleteUser struct {
+	userSvc   *user.Service
+	rbacSvc   *rbac.Service
+	tenantSvc *tenant.Service
+	twoFactor *user.TwoFactorAuth
+	token     *auth.Token
+	txm       db.TxManager
+	logger    logger.Logger
+}
+
+func NewDeleteUser(
+	userSvc *user.Service,
+	rbacSvc *rbac.Service,
+	tenantSvc *tenant.Service,
+	twoFactor *user.TwoFactorAuth,
+	token *auth.Token,
+	txm db.TxManager,
+	logger logger.Logger,
+) *DeleteUser {
+	return &DeleteUser{
+		userSvc:   userSvc,
+		rbacSvc:   rbacSvc,
+		tenantSvc: tenantSvc,
+		twoFactor: twoFactor,
+		token:     token,
+		txm:       txm,
+		logger:    logger.With("component", "app.delete_user"),
+	}
+}
+
+// Execute deletes the user with their roles, tenant memberships, two-factor
+// authentication, password resets and pending invitations, and revokes their
+// refresh sessions. The last admin cannot be deleted (rbac.ErrLastAdmin).
+func (d *DeleteUser) Execute(ctx context.Context, id uuid.UUID) error {
+	txErr := d.txm.Do(ctx, func(txCtx context.Context) error {
+		if _, err := d.userSvc.Get(txCtx, id); err != nil {
+			return fmt.Errorf("get user: %w", err)
+		}
+
+		if _, err := d.rbacSvc.SetUserRoles(txCtx, id, nil); err != nil {
+			return fmt.Errorf("revoke roles: %w", err)
+		}
+
+		if err := d.tenantSvc.RemoveUser(txCtx, id); err != nil {
+			return fmt.Errorf("remove memberships: %w", err)
+		}
+
+		if err := d.twoFactor.Remove(txCtx, id); err != nil {
+			return fmt.Errorf("remove two-factor: %w", err)
+		}
+
+		if err := d.token.RevokeAll(txCtx, id); err != nil {
+			return fmt.Errorf("revoke refresh sessions: %w", err)
+		}
+
+		if err := d.userSvc.Delete(txCtx, id); err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+
+		return nil
+	})
+
+	if txErr != nil {
+		return fmt.Errorf("delete user tx: %w", txErr)
+	}
+
+	d.logger.InfoContext(ctx, "user deleted", "user_id", id)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/auth"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type ResetPassword struct {
+	resetter *user.Resetter
+	token    *auth.Token
+	txm      db.TxManager
+	logger   logger.Logger
+}
+
+func NewResetPassword(
+	resetter *user.Resetter,
+	token *auth.Token,
+	txm db.TxManager,
+	logger logger.Logger,
+) *ResetPassword {
+	return &ResetPassword{
+		resetter: resetter,
+		token:    token,
+		txm:      txm,
+		logger:   logger.With("component", "app.reset_password"),
+	}
+}
+
+// Execute sets the new password of the token's user and revokes their
+// refresh sessions, so whoever knew the old password is signed out.
+func (r *ResetPassword) Execute(ctx context.Context, token, password string) error {
+	var userID uuid.UUID
+
+	txErr := r.txm.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		if userID, err = r.resetter.Reset(txCtx, token, password); err != nil {
+			return fmt.Errorf("reset password: %w", err)
+		}
+
+		if err = r.token.RevokeAll(txCtx, userID); err != nil {
+			return fmt.Errorf("revoke refresh sessions: %w", err)
+		}
+
+		return nil
+	})
+
+	if txErr != nil {
+		return fmt.Errorf("reset password tx: %w", txErr)
+	}
+
+	r.logger.InfoContext(ctx, "password reset", "user_id", userID)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/auth"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type UpdateUser struct {
+	userSvc *user.Service
+	token   *auth.Token
+	txm     db.TxManager
+	logger  logger.Logger
+}
+
+func NewUpdateUser(
+	userSvc *user.Service,
+	token *auth.Token,
+	txm db.TxManager,
+	logger logger.Logger,
+) *UpdateUser {
+	return &UpdateUser{
+		userSvc: userSvc,
+		token:   token,
+		txm:     txm,
+		logger:  logger.With("component", "app.update_user"),
+	}
+}
+
+// Execute updates the user. Setting a password also revokes their refresh
+// sessions, so whoever knew the old password is signed out.
+func (u *UpdateUser) Execute(ctx context.Context, id uuid.UUID, in user.UpdateInput) (*user.User, error) {
+	var out *user.User
+
+	txErr := u.txm.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		if out, err = u.userSvc.Update(txCtx, id, in); err != nil {
+			return fmt.Errorf("update user: %w", err)
+		}
+
+		if !in.Password.IsPresent {
+			return nil
+		}
+
+		if err = u.token.RevokeAll(txCtx, id); err != nil {
+			return fmt.Errorf("revoke refresh sessions: %w", err)
+		}
+
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, fmt.Errorf("update user tx: %w", txErr)
+	}
+
+	if in.Password.IsPresent {
+		u.logger.InfoContext(ctx, "user password set", "user_id", id)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/imaging"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/storage"
+)
+
+// MaxAvatarSize is the largest avatar upload accepted, in bytes.
+const MaxAvatarSize = 2 << 20
+
+// AvatarSizes are the square thumbnail sizes generated for every avatar;
+// the first one is used as the customer's ImageURL.
+var AvatarSizes = []int{256, 64}
+
+type UploadAvatar struct {
+	custSvc *customer.Service
+	storage storage.Storage
+	logger  logger.Logger
+}
+
+func NewUploadAvatar(
+	custSvc *customer.Service,
+	storage storage.Manager,
+	logger logger.Logger,
+) *UploadAvatar {
+	return &UploadAvatar{
+		custSvc: custSvc,
+		storage: storage,
+		logger:  logger.With("component", "app.upload_avatar"),
+	}
+}
+
+func (u *UploadAvatar) Execute(ctx context.Context, customerID uuid.UUID, r io.Reader) (*customer.Customer, error) {
+	cust, err := u.custSvc.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+	if cust.IsErased() {
+		return nil, customer.ErrCustomerErased
+	}
+
+	img, _, err := imaging.Decode(r, MaxAvatarSize)
+	if err != nil {
+		return nil, fmt.Errorf("decode avatar: %w", err)
+	}
+
+	var imageURL string
+
+	for i, size := range AvatarSizes {
+		data, err := imaging.EncodePNG(imaging.Thumbnail(img, size))
+		if err != nil {
+			return nil, fmt.Errorf("encode thumbnail: %w", err)
+		}
+
+		key := avatarKey(customerID, size)
+		if err = u.storage.Put(ctx, key, bytes.NewReader(data), "image/png"); err != nil {
+			return nil, fmt.Errorf("store thumbnail: %w", err)
+		}
+
+		if i == 0 {
+			// keys are stable per customer, so bust client caches on re-upload
+			imageURL = u.storage.URL(key) + "?v=" + strconv.FormatInt(time.Now().Unix(), 10)
+		}
+	}
+
+	cust, err = u.custSvc.SetImageURL(ctx, customerID, &imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("set image url: %w", err)
+	}
+
+	return cust, nil
+}
+
+func avatarKey(customerID uuid.UUID, size int) string {
+	return fmt.Sprintf("avatars/%s/%d.png", customerID, size)
+}
@@ -8,6 +8,7 @@ var (
 	ErrPasswordIncorrect        = errors.New("password incorrect")
 	ErrJWTExpired               = errors.New("JWT is expired")
 	ErrJWTInvalid               = errors.New("JWT is invalid")
+	ErrJWTRevoked               = errors.New("JWT is revoked")
 	ErrRefreshTokenExpired      = errors.New("refresh token is expired")
 	ErrRefreshTokenUserMismatch = errors.New("token user does not match")
 	ErrRefreshTokenUsed         = errors.New("refresh token is used")
@@ -0,0 +1,13 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+)
+
+// RefreshTokenReused is published when an already rotated refresh token is
+// presented again, a sign that it was stolen. The family is revoked by then.
+type RefreshTokenReused struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	FamilyID  uuid.UUID
+}
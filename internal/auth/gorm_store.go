@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/logger"
@@ -48,6 +49,47 @@ func (s *GormRefreshStore) Get(ctx context.Context, id uuid.UUID) (RefreshSessio
 	return r, nil
 }
 
+func (s *GormRefreshStore) ListByUser(ctx context.Context, userID uuid.UUID) ([]RefreshSession, error) {
+	var sessions []RefreshSession
+
+	if err := s.DB(ctx).Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("query refresh sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *GormRefreshStore) ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]RefreshSession, error) {
+	var sessions []RefreshSession
+
+	err := s.DB(ctx).
+		Where("user_id = ? AND used = ? AND expires_at > ?", userID, false, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query active refresh sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *GormRefreshStore) ListByFamily(ctx context.Context, familyID uuid.UUID) ([]RefreshSession, error) {
+	var sessions []RefreshSession
+
+	// sessions from before families were tracked are their own family
+	err := s.DB(ctx).
+		Where("family_id = ? OR id = ?", familyID, familyID).
+		Order("created_at").
+		Find(&sessions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query refresh session family: %w", err)
+	}
+
+	return sessions, nil
+}
+
 func (s *GormRefreshStore) Insert(ctx context.Context, refreshSession RefreshSession) (RefreshSession, error) {
 	start := time.Now()
 	if err := s.DB(ctx).Create(&refreshSession).Error; err != nil {
@@ -65,3 +107,100 @@ func (s *GormRefreshStore) Update(ctx context.Context, refreshSession RefreshSes
 	}
 	return nil
 }
+
+func (s *GormRefreshStore) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.DB(ctx).Where("user_id = ?", userID).Delete(&RefreshSession{}).Error; err != nil {
+		return fmt.Errorf("delete refresh sessions: %w", err)
+	}
+	return nil
+}
+
+// MarkUsed only marks a session not used yet, so two concurrent exchanges
+// of the same refresh token cannot both succeed.
+func (s *GormRefreshStore) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	res := s.DB(ctx).
+		Model(&RefreshSession{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("mark refresh session used: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *GormRefreshStore) DeleteByFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := s.DB(ctx).Where("family_id = ? OR id = ?", familyID, familyID).Delete(&RefreshSession{}).Error; err != nil {
+		return fmt.Errorf("delete refresh session family: %w", err)
+	}
+	return nil
+}
+
+func (s *GormRefreshStore) Delete(ctx context.Context, id uuid.UUID) error {
+	res := s.DB(ctx).Delete(&RefreshSession{}, "id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("delete refresh session: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrRefreshSessionNotFound
+	}
+	return nil
+}
+
+type deniedTokenModel struct {
+	JTI       string    `gorm:"type:varchar(64);not nullable;primary_key"`
+	ExpiresAt time.Time `gorm:"not nullable;index"`
+}
+
+func (*deniedTokenModel) TableName() string {
+	return "denied_tokens"
+}
+
+type GormDenyStore struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+var _ DenyStore = (*GormDenyStore)(nil)
+
+func NewGormDenyStore(db *gorm.DB, logger logger.Logger) *GormDenyStore {
+	return &GormDenyStore{
+		db:     db,
+		logger: logger.With("component", "store.gorm.deny"),
+	}
+}
+
+func (s *GormDenyStore) DB(ctx context.Context) *gorm.DB {
+	if gormDB, ok := db.FromCtx(ctx); ok {
+		return gormDB.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+// Deny records the token and drops entries whose tokens have expired by
+// themselves, which keeps the table to the tokens still worth checking.
+func (s *GormDenyStore) Deny(ctx context.Context, jti string, until time.Time) error {
+	model := deniedTokenModel{JTI: jti, ExpiresAt: until}
+
+	if err := s.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model).Error; err != nil {
+		return fmt.Errorf("insert denied token: %w", err)
+	}
+
+	if err := s.DB(ctx).Where("expires_at < ?", time.Now()).Delete(&deniedTokenModel{}).Error; err != nil {
+		s.logger.WarnContext(ctx, "failed to purge denied tokens", "error", err.Error())
+	}
+
+	return nil
+}
+
+func (s *GormDenyStore) IsDenied(ctx context.Context, jti string) (bool, error) {
+	tx := s.DB(ctx).Model(&deniedTokenModel{}).Where("jti = ? AND expires_at >= ?", jti, time.Now())
+
+	denied, err := db.RecordExists(tx)
+	if err != nil {
+		return false, fmt.Errorf("query denied token: %w", err)
+	}
+
+	return denied, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/rbac"
 	"github.com/gelozr/go-dash/internal/user"
 )
 
@@ -30,62 +31,105 @@ type AccessClaims struct {
 	ID        string
 
 	UserID uuid.UUID
+	// SessionID is the refresh session the token was issued with.
+	SessionID uuid.UUID
 }
 
 type JWTClaims struct {
-	UserID uuid.UUID
+	UserID    uuid.UUID
+	SessionID uuid.UUID
 	jwt.RegisteredClaims
 }
 
+const accessTokenTTL = 15 * time.Minute
+
 type JWTDriver struct {
-	hmacKey           []byte
+	keys *KeySet
+	// iss and aud of the tokens, checked when parsing
+	issuer            string
+	audience          string
 	refreshSessionSvc *Token
+	userSvc           *user.Service
+	rbacSvc           *rbac.Service
 }
 
-func NewJWTDriver(cfg *config.Config, refreshSessionSvc *Token) *JWTDriver {
+func NewJWTDriver(cfg *config.Config, keys *KeySet, refreshSessionSvc *Token, userSvc *user.Service, rbacSvc *rbac.Service) *JWTDriver {
 	return &JWTDriver{
-		hmacKey:           []byte(cfg.JWTHmacKey),
+		keys:              keys,
+		issuer:            cfg.JWTIssuer,
+		audience:          cfg.JWTAudience,
 		refreshSessionSvc: refreshSessionSvc,
+		userSvc:           userSvc,
+		rbacSvc:           rbacSvc,
 	}
 }
 
-func (d *JWTDriver) Sign(uid uuid.UUID) (string, time.Time, error) {
-	claims := JWTClaims{
-		UserID: uid,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        uuid.NewString(),
-			Issuer:    "myapp",
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-		},
+// newAccessRef picks the ID and expiry of the next access token, which are
+// stored with its refresh session before it is signed.
+func newAccessRef() AccessRef {
+	return AccessRef{
+		ID:        uuid.NewString(),
+		ExpiresAt: time.Now().Add(accessTokenTTL).Truncate(time.Second),
 	}
+}
+
+// Keys returns the public keys tokens are currently verified with, for
+// services checking them on their own.
+func (d *JWTDriver) Keys() []SigningKey {
+	return d.keys.Published(time.Now())
+}
 
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+func (d *JWTDriver) Sign(uid, sid uuid.UUID, access AccessRef) (string, error) {
+	now := time.Now()
 
-	signed, err := tok.SignedString(d.hmacKey)
+	key, err := d.keys.Signing(now)
 	if err != nil {
-		return "", time.Now(), fmt.Errorf("signing token: %w", err)
+		return "", fmt.Errorf("signing key: %w", err)
 	}
 
-	exp, err := tok.Claims.GetExpirationTime()
+	claims := JWTClaims{
+		UserID:    uid,
+		SessionID: sid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        access.ID,
+			Issuer:    d.issuer,
+			Subject:   uid.String(),
+			Audience:  jwt.ClaimStrings{d.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(access.ExpiresAt),
+		},
+	}
+
+	tok := jwt.NewWithClaims(key.method(), claims)
+	tok.Header["kid"] = key.ID
+
+	signed, err := tok.SignedString(key.private)
 	if err != nil {
-		return "", time.Now(), fmt.Errorf("getting expiration time: %w", err)
+		return "", fmt.Errorf("signing token: %w", err)
 	}
 
-	return signed, exp.Time, nil
+	return signed, nil
 }
 
 func (d *JWTDriver) Parse(tokenStr string) (AccessClaims, error) {
-	tok, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, func(t *jwt.Token) (any, error) {
-		return d.hmacKey, nil
-	})
+	tok, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, d.verifyingKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(d.issuer),
+		jwt.WithAudience(d.audience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		switch {
-		case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenMalformed):
-			return AccessClaims{}, ErrJWTInvalid
 		case errors.Is(err, jwt.ErrTokenExpired):
 			return AccessClaims{}, ErrJWTExpired
+		case errors.Is(err, jwt.ErrTokenSignatureInvalid),
+			errors.Is(err, jwt.ErrTokenMalformed),
+			errors.Is(err, jwt.ErrTokenUnverifiable),
+			errors.Is(err, jwt.ErrTokenInvalidIssuer),
+			errors.Is(err, jwt.ErrTokenInvalidAudience),
+			errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+			return AccessClaims{}, ErrJWTInvalid
 		default:
 			return AccessClaims{}, fmt.Errorf("parsing token: %w", err)
 		}
@@ -99,34 +143,50 @@ func (d *JWTDriver) Parse(tokenStr string) (AccessClaims, error) {
 		Audience:  ac.Audience,
 		ExpiresAt: ac.ExpiresAt.Time,
 		// NotBefore: ac.NotBefore.Time,
-		IssuedAt: ac.IssuedAt.Time,
-		ID:       ac.ID,
-		UserID:   ac.UserID,
+		IssuedAt:  ac.IssuedAt.Time,
+		ID:        ac.ID,
+		UserID:    ac.UserID,
+		SessionID: ac.SessionID,
 	}
 
 	return claims, nil
 }
 
+// verifyingKey finds the public key of the token's kid, which must be of
+// the token's algorithm.
+func (d *JWTDriver) verifyingKey(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	k, ok := d.keys.Verifying(kid, time.Now())
+	if !ok || k.Alg != t.Method.Alg() {
+		return nil, ErrJWTInvalid
+	}
+
+	return k.Public(), nil
+}
+
 func (d *JWTDriver) IssueToken(ctx context.Context, usr any) (any, error) {
 	u, ok := usr.(*user.User)
 	if !ok {
 		return nil, errors.New("invalid user id")
 	}
 
-	jwtStr, exp, err := d.Sign(u.ID)
+	access := newAccessRef()
+
+	refreshSess, err := d.refreshSessionSvc.CreateRefresh(ctx, u.ID, access)
 	if err != nil {
-		return nil, fmt.Errorf("sign jwt: %w", err)
+		return nil, fmt.Errorf("create refresh session: %w", err)
 	}
 
-	refreshSess, err := d.refreshSessionSvc.CreateRefresh(ctx, u.ID)
+	jwtStr, err := d.Sign(u.ID, refreshSess.ID, access)
 	if err != nil {
-		return nil, fmt.Errorf("create refresh session: %w", err)
+		return nil, fmt.Errorf("sign jwt: %w", err)
 	}
 
 	accessToken := AccessToken{
 		AccessToken:  jwtStr,
 		RefreshToken: refreshSess.ID.String(),
-		ExpiresIn:    int(time.Until(exp).Seconds()),
+		ExpiresIn:    int(time.Until(access.ExpiresAt).Seconds()),
 	}
 
 	return accessToken, nil
@@ -147,8 +207,35 @@ func (d *JWTDriver) Validate(ctx context.Context, payload any) (auth.Verified[an
 		return auth.Verified[any]{}, fmt.Errorf("parse token: %w", err)
 	}
 
+	denied, err := d.refreshSessionSvc.IsDenied(ctx, claims.ID)
+	if err != nil {
+		return auth.Verified[any]{}, fmt.Errorf("check denylist: %w", err)
+	}
+	if denied {
+		return auth.Verified[any]{}, ErrJWTRevoked
+	}
+
+	// looked up on every request so role changes apply immediately
+	u, err := d.userSvc.Get(ctx, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return auth.Verified[any]{}, ErrJWTInvalid
+		default:
+			return auth.Verified[any]{}, fmt.Errorf("get user: %w", err)
+		}
+	}
+
+	grants, err := d.rbacSvc.Grants(ctx, u.ID)
+	if err != nil {
+		return auth.Verified[any]{}, fmt.Errorf("get grants: %w", err)
+	}
+
+	u.Roles = grants.Roles
+	u.Permissions = grants.Permissions
+
 	return auth.Verified[any]{
-		User: user.User{ID: claims.UserID},
+		User: *u,
 	}, nil
 }
 
@@ -163,12 +250,14 @@ func (d *JWTDriver) RefreshToken(ctx context.Context, refreshToken string) (any,
 		return nil, fmt.Errorf("get refresh session: %w", err)
 	}
 
-	newRefresh, err := d.refreshSessionSvc.ExchangeRefresh(ctx, currRefresh)
+	access := newAccessRef()
+
+	newRefresh, err := d.refreshSessionSvc.ExchangeRefresh(ctx, currRefresh, access)
 	if err != nil {
 		return nil, fmt.Errorf("exchange refresh: %w", err)
 	}
 
-	jwtStr, exp, err := d.Sign(newRefresh.UserID)
+	jwtStr, err := d.Sign(newRefresh.UserID, newRefresh.ID, access)
 	if err != nil {
 		return nil, fmt.Errorf("sign jwt: %w", err)
 	}
@@ -176,8 +265,47 @@ func (d *JWTDriver) RefreshToken(ctx context.Context, refreshToken string) (any,
 	accessToken := AccessToken{
 		AccessToken:  jwtStr,
 		RefreshToken: newRefresh.ID.String(),
-		ExpiresIn:    int(time.Until(exp).Seconds()),
+		ExpiresIn:    int(time.Until(access.ExpiresAt).Seconds()),
 	}
 
 	return accessToken, nil
 }
+
+// Logout ends the session of the access token: its refresh token stops
+// working and the access token is denied until it expires.
+func (d *JWTDriver) Logout(ctx context.Context, token string) error {
+	claims, err := d.Parse(token)
+	if err != nil {
+		return fmt.Errorf("parse token: %w", err)
+	}
+
+	// tokens from before sessions were recorded name none
+	err = d.refreshSessionSvc.Revoke(ctx, claims.UserID, claims.SessionID)
+	if err != nil && !errors.Is(err, ErrRefreshSessionNotFound) {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	if err = d.refreshSessionSvc.Deny(ctx, AccessRef{ID: claims.ID, ExpiresAt: claims.ExpiresAt}); err != nil {
+		return fmt.Errorf("deny token: %w", err)
+	}
+
+	return nil
+}
+
+// LogoutAll ends every session of the access token's user.
+func (d *JWTDriver) LogoutAll(ctx context.Context, token string) error {
+	claims, err := d.Parse(token)
+	if err != nil {
+		return fmt.Errorf("parse token: %w", err)
+	}
+
+	if err = d.refreshSessionSvc.RevokeAll(ctx, claims.UserID); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+
+	if err = d.refreshSessionSvc.Deny(ctx, AccessRef{ID: claims.ID, ExpiresAt: claims.ExpiresAt}); err != nil {
+		return fmt.Errorf("deny token: %w", err)
+	}
+
+	return nil
+}
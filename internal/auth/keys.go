@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+// ActivatesAtHeader is the PEM header scheduling when a key starts signing,
+// in RFC 3339. Keys without it sign as soon as they are loaded.
+const ActivatesAtHeader = "Activates-At"
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// SigningKey is a private key access tokens are signed with, named by the
+// kid header of the tokens.
+type SigningKey struct {
+	ID  string
+	Alg string // "RS256" | "EdDSA"
+	// ActivatesAt is when the key starts signing; it is published before.
+	ActivatesAt time.Time
+
+	private crypto.Signer
+}
+
+func (k SigningKey) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+func (k SigningKey) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Alg)
+}
+
+// KeySet holds the signing keys. The most recently activated key signs;
+// the key it replaced stays published until the tokens it signed have
+// expired, and scheduled keys are published ahead so verifiers have them
+// before the first token they sign.
+type KeySet struct {
+	// ordered by activation
+	keys []SigningKey
+	// how long a replaced key is kept
+	overlap time.Duration
+}
+
+// NewKeySet loads the <kid>.pem keys of JWT_KEYS_DIR. Without a directory
+// it makes a throwaway key, except in production and staging.
+func NewKeySet(cfg *config.Config, log logger.Logger) (*KeySet, error) {
+	log = log.With("component", "auth.keys")
+
+	var keys []SigningKey
+
+	if cfg.JWTKeysDir == "" {
+		if cfg.AppEnv != config.Local {
+			return nil, errors.New("JWT_KEYS_DIR is required")
+		}
+
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate key: %w", err)
+		}
+
+		log.Warn("JWT_KEYS_DIR not set, signing with a throwaway key")
+		keys = append(keys, SigningKey{ID: "local", Alg: jwt.SigningMethodEdDSA.Alg(), private: private})
+	} else {
+		var err error
+		if keys, err = loadKeys(cfg.JWTKeysDir); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(keys, func(a, b SigningKey) int {
+		if c := a.ActivatesAt.Compare(b.ActivatesAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	for _, k := range keys {
+		log.Info("loaded signing key", "kid", k.ID, "alg", k.Alg, "activates_at", k.ActivatesAt)
+	}
+
+	return &KeySet{keys: keys, overlap: accessTokenTTL}, nil
+}
+
+// Signing returns the key signing at now.
+func (s *KeySet) Signing(now time.Time) (SigningKey, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !s.keys[i].ActivatesAt.After(now) {
+			return s.keys[i], nil
+		}
+	}
+	return SigningKey{}, ErrNoSigningKey
+}
+
+// Published returns the keys tokens may be verified with at now: the
+// signing key, the one it replaced during the overlap, and scheduled keys.
+func (s *KeySet) Published(now time.Time) []SigningKey {
+	var keys []SigningKey
+	for i, k := range s.keys {
+		if i+1 < len(s.keys) && !s.keys[i+1].ActivatesAt.Add(s.overlap).After(now) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Verifying returns the published key with the kid.
+func (s *KeySet) Verifying(kid string, now time.Time) (SigningKey, bool) {
+	for _, k := range s.Published(now) {
+		if k.ID == kid {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func loadKeys(dir string) ([]SigningKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read keys dir: %w", err)
+	}
+
+	var keys []SigningKey
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".pem" {
+			continue
+		}
+
+		k, err := loadKey(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", e.Name(), err)
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no .pem keys in %s", dir)
+	}
+
+	return keys, nil
+}
+
+func loadKey(path string) (SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("read file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errors.New("no PEM block")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("parse key: %w", err)
+	}
+
+	k := SigningKey{ID: strings.TrimSuffix(filepath.Base(path), ".pem")}
+
+	switch p := parsed.(type) {
+	case *rsa.PrivateKey:
+		if p.N.BitLen() < 2048 {
+			return SigningKey{}, errors.New("RSA keys must be at least 2048 bits")
+		}
+		k.Alg, k.private = jwt.SigningMethodRS256.Alg(), p
+	case ed25519.PrivateKey:
+		k.Alg, k.private = jwt.SigningMethodEdDSA.Alg(), p
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if v, ok := block.Headers[ActivatesAtHeader]; ok {
+		if k.ActivatesAt, err = time.Parse(time.RFC3339, v); err != nil {
+			return SigningKey{}, fmt.Errorf("parse %s header: %w", ActivatesAtHeader, err)
+		}
+	}
+
+	return k, nil
+}
@@ -14,16 +14,84 @@ var (
 
 type RefreshStore interface {
 	Get(context.Context, uuid.UUID) (RefreshSession, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]RefreshSession, error)
+	// ListActiveByUser lists the unused, unexpired sessions of the user: the
+	// latest of each family.
+	ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]RefreshSession, error)
 	Insert(context.Context, RefreshSession) (RefreshSession, error)
 	Update(context.Context, RefreshSession) error
+	// MarkUsed marks the session used, reporting false if it already was.
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	ListByFamily(ctx context.Context, familyID uuid.UUID) ([]RefreshSession, error)
+	DeleteByFamily(ctx context.Context, familyID uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// DeleteByUser removes every refresh session of the user.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// DenyStore holds revoked access tokens by JWT ID until they expire.
+type DenyStore interface {
+	Deny(ctx context.Context, jti string, until time.Time) error
+	IsDenied(ctx context.Context, jti string) (bool, error)
 }
 
 type RefreshSession struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// FamilyID is shared by the sessions rotated from one login; it is the
+	// ID of the first of them.
+	FamilyID  uuid.UUID
 	ExpiresAt time.Time
 	Used      bool
 	CreatedAt time.Time
+
+	// the access token issued with the session, denied when it is revoked
+	AccessTokenID   string
+	AccessExpiresAt time.Time
+
+	// the client the session was issued to
+	UserAgent string
+	IP        string
+	// SignedInAt is when the family started, LastUsedAt when the session was
+	// issued by a login or refresh.
+	SignedInAt time.Time
+	LastUsedAt time.Time
+}
+
+// Client describes who requests a session.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+type ctxKey string
+
+var clientCtxKey = ctxKey("client")
+
+// WithClient records the client that sessions created with the context are
+// issued to.
+func WithClient(ctx context.Context, c Client) context.Context {
+	return context.WithValue(ctx, clientCtxKey, c)
+}
+
+func ClientFromCtx(ctx context.Context) Client {
+	c, _ := ctx.Value(clientCtxKey).(Client)
+	return c
+}
+
+// Family returns the family of the session; sessions created before
+// families were tracked form their own.
+func (r RefreshSession) Family() uuid.UUID {
+	if r.FamilyID == uuid.Nil {
+		return r.ID
+	}
+	return r.FamilyID
+}
+
+// AccessRef identifies an access token by its JWT ID and expiry.
+type AccessRef struct {
+	ID        string
+	ExpiresAt time.Time
 }
 
 type UpdateRefreshInput struct {
@@ -2,20 +2,30 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/event"
 )
 
 type Token struct {
 	refreshStore RefreshStore
+	denyStore    DenyStore
+	txm          db.TxManager
+	event        event.Publisher
 	// logger       logger.Logger
 }
 
-func NewToken(refreshStore RefreshStore) *Token {
+func NewToken(refreshStore RefreshStore, denyStore DenyStore, txm db.TxManager, evt event.Publisher) *Token {
 	return &Token{
 		refreshStore: refreshStore,
+		denyStore:    denyStore,
+		txm:          txm,
+		event:        evt,
 		// logger:       logger.With("component", "auth"),
 	}
 }
@@ -28,13 +38,40 @@ func (a *Token) GetRefresh(ctx context.Context, id uuid.UUID) (RefreshSession, e
 	return r, nil
 }
 
-func (a *Token) CreateRefresh(ctx context.Context, uid uuid.UUID) (RefreshSession, error) {
+// CreateRefresh starts a session family for a login.
+func (a *Token) CreateRefresh(ctx context.Context, uid uuid.UUID, access AccessRef) (RefreshSession, error) {
+	return a.createRefresh(ctx, uid, nil, access)
+}
+
+// createRefresh creates a session continuing the family of prev, or a new
+// family if prev is nil, for the client of the context.
+func (a *Token) createRefresh(ctx context.Context, uid uuid.UUID, prev *RefreshSession, access AccessRef) (RefreshSession, error) {
+	now := time.Now()
+	id := uuid.New()
+
+	familyID, signedInAt := id, now
+	if prev != nil {
+		familyID, signedInAt = prev.Family(), prev.SignedInAt
+		if signedInAt.IsZero() {
+			signedInAt = prev.CreatedAt
+		}
+	}
+
+	client := ClientFromCtx(ctx)
+
 	r, err := a.refreshStore.Insert(ctx, RefreshSession{
-		ID:        uuid.New(),
-		UserID:    uid,
-		Used:      false,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
-		CreatedAt: time.Now(),
+		ID:              id,
+		UserID:          uid,
+		FamilyID:        familyID,
+		Used:            false,
+		ExpiresAt:       now.Add(7 * 24 * time.Hour),
+		CreatedAt:       now,
+		AccessTokenID:   access.ID,
+		AccessExpiresAt: access.ExpiresAt,
+		UserAgent:       client.UserAgent,
+		IP:              client.IP,
+		SignedInAt:      signedInAt,
+		LastUsedAt:      now,
 	})
 
 	if err != nil {
@@ -44,24 +81,169 @@ func (a *Token) CreateRefresh(ctx context.Context, uid uuid.UUID) (RefreshSessio
 	return r, nil
 }
 
-func (a *Token) ExchangeRefresh(ctx context.Context, currRefresh RefreshSession) (RefreshSession, error) {
+// ExchangeRefresh rotates the session into a new one of the same family.
+// Presenting a session that was already rotated revokes the whole family,
+// since either the holder or a thief now has the newer token.
+func (a *Token) ExchangeRefresh(ctx context.Context, currRefresh RefreshSession, access AccessRef) (RefreshSession, error) {
 	if currRefresh.ExpiresAt.Before(time.Now()) {
 		return RefreshSession{}, ErrRefreshTokenExpired
 	}
 
 	if currRefresh.Used {
-		return RefreshSession{}, ErrRefreshTokenUsed
+		return RefreshSession{}, a.reused(ctx, currRefresh)
 	}
 
-	currRefresh.Used = true
-	if err := a.refreshStore.Update(ctx, currRefresh); err != nil {
-		return RefreshSession{}, fmt.Errorf("update refresh session: %w", err)
-	}
+	var newRefresh RefreshSession
+
+	err := a.txm.Do(ctx, func(ctx context.Context) error {
+		ok, err := a.refreshStore.MarkUsed(ctx, currRefresh.ID)
+		if err != nil {
+			return fmt.Errorf("mark refresh session used: %w", err)
+		}
+		// a concurrent exchange won
+		if !ok {
+			return ErrRefreshTokenUsed
+		}
+
+		newRefresh, err = a.createRefresh(ctx, currRefresh.UserID, &currRefresh, access)
+		if err != nil {
+			return fmt.Errorf("create refresh token: %w", err)
+		}
+
+		return nil
+	})
 
-	newRefresh, err := a.CreateRefresh(ctx, currRefresh.UserID)
 	if err != nil {
-		return RefreshSession{}, fmt.Errorf("create refresh token: %w", err)
+		if errors.Is(err, ErrRefreshTokenUsed) {
+			return RefreshSession{}, a.reused(ctx, currRefresh)
+		}
+		return RefreshSession{}, fmt.Errorf("exchange refresh tx: %w", err)
 	}
 
 	return newRefresh, nil
 }
+
+// reused revokes the family of a replayed session and reports it, returning
+// ErrRefreshTokenUsed.
+func (a *Token) reused(ctx context.Context, r RefreshSession) error {
+	if err := a.RevokeFamily(ctx, r.Family()); err != nil {
+		return fmt.Errorf("revoke family: %w", err)
+	}
+
+	err := a.event.Publish(ctx, RefreshTokenReused{
+		UserID:    r.UserID,
+		SessionID: r.ID,
+		FamilyID:  r.Family(),
+	})
+	if err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
+
+	return ErrRefreshTokenUsed
+}
+
+// ListSessions returns the active sessions of the user, one per family and
+// most recently used first.
+func (a *Token) ListSessions(ctx context.Context, uid uuid.UUID) ([]RefreshSession, error) {
+	sessions, err := a.refreshStore.ListActiveByUser(ctx, uid, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("list active refresh sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// Revoke ends the session of the user with the refresh session id, along
+// with its family, and denies the access tokens issued with them.
+func (a *Token) Revoke(ctx context.Context, uid, id uuid.UUID) error {
+	r, err := a.refreshStore.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get refresh session: %w", err)
+	}
+	if r.UserID != uid {
+		return ErrRefreshTokenUserMismatch
+	}
+
+	return a.RevokeFamily(ctx, r.Family())
+}
+
+// RevokeSession ends the session family of the user, failing with
+// ErrRefreshSessionNotFound when the user has no such session.
+func (a *Token) RevokeSession(ctx context.Context, uid, familyID uuid.UUID) error {
+	sessions, err := a.refreshStore.ListByFamily(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("list refresh session family: %w", err)
+	}
+
+	if len(sessions) == 0 || sessions[0].UserID != uid {
+		return ErrRefreshSessionNotFound
+	}
+
+	return a.RevokeFamily(ctx, familyID)
+}
+
+// RevokeAll ends every refresh session of the user and denies the access
+// tokens issued with them, signing the user out everywhere.
+func (a *Token) RevokeAll(ctx context.Context, uid uuid.UUID) error {
+	sessions, err := a.refreshStore.ListByUser(ctx, uid)
+	if err != nil {
+		return fmt.Errorf("list refresh sessions: %w", err)
+	}
+
+	for _, r := range sessions {
+		if err = a.denyAccess(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	if err = a.refreshStore.DeleteByUser(ctx, uid); err != nil {
+		return fmt.Errorf("delete refresh sessions: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeFamily ends every session of the family and denies the access
+// tokens issued with them.
+func (a *Token) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	sessions, err := a.refreshStore.ListByFamily(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("list refresh session family: %w", err)
+	}
+
+	for _, r := range sessions {
+		if err = a.denyAccess(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	if err = a.refreshStore.DeleteByFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("delete refresh session family: %w", err)
+	}
+
+	return nil
+}
+
+// Deny revokes the access token until it expires by itself.
+func (a *Token) Deny(ctx context.Context, access AccessRef) error {
+	if access.ID == "" || !access.ExpiresAt.After(time.Now()) {
+		return nil
+	}
+
+	if err := a.denyStore.Deny(ctx, access.ID, access.ExpiresAt); err != nil {
+		return fmt.Errorf("deny access token: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Token) IsDenied(ctx context.Context, jti string) (bool, error) {
+	denied, err := a.denyStore.IsDenied(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("is denied: %w", err)
+	}
+	return denied, nil
+}
+
+func (a *Token) denyAccess(ctx context.Context, r RefreshSession) error {
+	return a.Deny(ctx, AccessRef{ID: r.AccessTokenID, ExpiresAt: r.AccessExpiresAt})
+}
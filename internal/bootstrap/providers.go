@@ -1,7 +1,10 @@
 package bootstrap
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/google/wire"
 	"gorm.io/gorm"
@@ -12,6 +15,7 @@ import (
 	"github.com/gelozr/go-dash/internal/auth"
 	"github.com/gelozr/go-dash/internal/config"
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/dashboard"
 	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/event"
@@ -24,6 +28,9 @@ import (
 	"github.com/gelozr/go-dash/internal/logger"
 	"github.com/gelozr/go-dash/internal/logger/slog"
 	"github.com/gelozr/go-dash/internal/mail"
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/storage"
+	"github.com/gelozr/go-dash/internal/tenant"
 	"github.com/gelozr/go-dash/internal/user"
 )
 
@@ -36,7 +43,7 @@ var AppServiceProviders = wire.NewSet(
 	wire.Bind(new(logger.Logger), new(*slog.Logger)),
 
 	// DB
-	db.Open,
+	DBProvider,
 	db.NewTxManager,
 	wire.Bind(new(db.TxManager), new(*db.GormTxManager)),
 
@@ -56,10 +63,16 @@ var AppServiceProviders = wire.NewSet(
 	mail.NewManager,
 	wire.Bind(new(mail.Mailer), new(mail.Manager)),
 
+	// STORAGE
+	storage.NewManager,
+
 	// AUTH
 	auth.NewGormRefreshStore,
 	wire.Bind(new(auth.RefreshStore), new(*auth.GormRefreshStore)),
+	auth.NewGormDenyStore,
+	wire.Bind(new(auth.DenyStore), new(*auth.GormDenyStore)),
 	auth.NewToken,
+	auth.NewKeySet,
 	auth.NewDBUserProvider,
 	auth.NewJWTDriver,
 	AuthProvider,
@@ -71,17 +84,39 @@ var AppServiceProviders = wire.NewSet(
 	wire.Bind(new(himoauth.Auth), new(*himoauth.Manager)),
 
 	// STORE & SERVICES
+	customfield.NewStore,
+	wire.Bind(new(customfield.Store), new(*customfield.GormStore)),
+	customfield.NewService,
+
 	customer.NewStore,
 	wire.Bind(new(customer.Store), new(*customer.GormStore)),
 	customer.NewService,
+	customer.NewVerifier,
 
 	user.NewStore,
 	wire.Bind(new(user.Store), new(*user.GormStore)),
 	user.NewService,
+	user.NewInviter,
+	user.NewResetter,
+	user.NewTwoFactorAuth,
+
+	rbac.NewStore,
+	wire.Bind(new(rbac.Store), new(*rbac.GormStore)),
+	rbac.NewService,
+
+	tenant.NewStore,
+	wire.Bind(new(tenant.Store), new(*tenant.GormStore)),
+	tenant.NewService,
+	RBACProvider,
 
 	dashboard.NewStore,
-	wire.Bind(new(dashboard.Store), new(*dashboard.GormStore)),
+	dashboard.NewCachedStore,
+	wire.Bind(new(dashboard.Store), new(*dashboard.CachedStore)),
 	dashboard.NewService,
+	dashboard.NewFeed,
+	dashboard.NewLayoutStore,
+	wire.Bind(new(dashboard.LayoutStore), new(*dashboard.GormLayoutStore)),
+	dashboard.NewLayoutService,
 
 	invoice.NewStore,
 	wire.Bind(new(invoice.Store), new(*invoice.GormStore)),
@@ -89,6 +124,13 @@ var AppServiceProviders = wire.NewSet(
 
 	// USE CASES
 	app.NewCreateInvoice,
+	app.NewUploadAvatar,
+	app.NewExportCustomerData,
+	app.NewEraseCustomer,
+	app.NewCustomerStatement,
+	app.NewUpdateUser,
+	app.NewDeleteUser,
+	app.NewResetPassword,
 )
 
 var HTTPProviders = wire.NewSet(
@@ -97,7 +139,16 @@ var HTTPProviders = wire.NewSet(
 	http.NewDashboardHandler,
 	http.NewUserHandler,
 	http.NewCustomerHandler,
+	http.NewCustomerDataHandler,
+	http.NewStatementHandler,
 	http.NewInvoiceHandler,
+	http.NewCustomFieldHandler,
+	http.NewStreamHandler,
+	http.NewLayoutHandler,
+	http.NewRoleHandler,
+	http.NewSessionHandler,
+	http.NewTwoFactorHandler,
+	http.NewTenantHandler,
 
 	// ENGINE
 	http.NewFiberServer,
@@ -107,6 +158,21 @@ var HTTPProviders = wire.NewSet(
 	http.SetupFiberRoutes,
 )
 
+// DBProvider opens the database with tenant scoping of the customer, invoice
+// and custom field definition tables.
+func DBProvider(cfg *config.Config, log logger.Logger) (*gorm.DB, error) {
+	gormDB, err := db.Open(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gormDB.Use(tenant.NewPlugin("customers", "invoices", "custom_field_definitions")); err != nil {
+		return nil, fmt.Errorf("use tenant plugin: %w", err)
+	}
+
+	return gormDB, nil
+}
+
 func AuthProvider(dbUserProvider *auth.DBUserProvider, jwtDriver *auth.JWTDriver) (*himoauth.Manager, error) {
 	a := himoauth.New()
 	opt := himoauth.HandlerOption{Driver: jwtDriver, UserProvider: dbUserProvider}
@@ -122,6 +188,44 @@ func AuthProvider(dbUserProvider *auth.DBUserProvider, jwtDriver *auth.JWTDriver
 	return a, nil
 }
 
+type RBACInitializer struct{}
+
+// RBACProvider seeds the default roles and makes the ADMIN_EMAIL user an
+// admin, so a fresh install has someone to assign roles.
+func RBACProvider(cfg *config.Config, rbacSvc *rbac.Service, userSvc *user.Service, logger logger.Logger) (RBACInitializer, error) {
+	ctx := context.Background()
+
+	if err := rbacSvc.SeedDefaults(ctx); err != nil {
+		return RBACInitializer{}, fmt.Errorf("seed roles: %w", err)
+	}
+
+	if cfg.AdminEmail == "" {
+		return RBACInitializer{}, nil
+	}
+
+	u, err := userSvc.GetByEmail(ctx, cfg.AdminEmail)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			logger.Warn("admin user not found", "email", cfg.AdminEmail)
+			return RBACInitializer{}, nil
+		}
+		return RBACInitializer{}, fmt.Errorf("get admin user: %w", err)
+	}
+
+	grants, err := rbacSvc.Grants(ctx, u.ID)
+	if err != nil {
+		return RBACInitializer{}, fmt.Errorf("get admin grants: %w", err)
+	}
+
+	if !slices.Contains(grants.Roles, rbac.RoleAdmin) {
+		if _, err := rbacSvc.SetUserRoles(ctx, u.ID, append(grants.Roles, rbac.RoleAdmin)); err != nil {
+			return RBACInitializer{}, fmt.Errorf("grant admin: %w", err)
+		}
+	}
+
+	return RBACInitializer{}, nil
+}
+
 func AppProvider(
 	cfg *config.Config,
 	db *gorm.DB,
@@ -129,6 +233,7 @@ func AppProvider(
 	fiberServer *http.FiberServer,
 	_ registry.RegisterInitializer,
 	_ http.RouteInitializer,
+	_ RBACInitializer,
 ) (*App, error) {
 	sqlDB, err := db.DB()
 	if err != nil {
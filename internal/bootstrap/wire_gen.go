@@ -11,6 +11,7 @@ import (
 	"github.com/gelozr/go-dash/internal/auth"
 	"github.com/gelozr/go-dash/internal/config"
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/dashboard"
 	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/event"
@@ -21,6 +22,9 @@ import (
 	"github.com/gelozr/go-dash/internal/invoice"
 	"github.com/gelozr/go-dash/internal/logger/slog"
 	"github.com/gelozr/go-dash/internal/mail"
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/storage"
+	"github.com/gelozr/go-dash/internal/tenant"
 	"github.com/gelozr/go-dash/internal/user"
 )
 
@@ -35,7 +39,7 @@ func InitApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	gormDB, err := db.Open(configConfig, logger)
+	gormDB, err := DBProvider(configConfig, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -43,15 +47,28 @@ func InitApp() (*App, error) {
 	broker := event.NewBroker()
 	gormStore := customer.NewStore(gormDB, logger)
 	service := customer.NewService(gormStore, broker, logger)
+	gormTxManager := db.NewTxManager(gormDB)
+	verifier, err := customer.NewVerifier(configConfig, gormStore, gormTxManager, broker, logger)
+	if err != nil {
+		return nil, err
+	}
 	manager := mail.NewManager(configConfig)
-	registerInitializer := registry.RegisterAll(broker, service, manager, logger)
+	dashboardGormStore := dashboard.NewStore(gormDB, configConfig, logger)
+	cachedStore := dashboard.NewCachedStore(dashboardGormStore, configConfig, logger)
 	userGormStore := user.NewStore(gormDB, logger)
-	userService := user.NewService(userGormStore, logger)
 	hashingManager := hashing.NewManager(configConfig)
+	userService := user.NewService(userGormStore, hashingManager, logger)
 	dbUserProvider := auth.NewDBUserProvider(userService, hashingManager)
 	gormRefreshStore := auth.NewGormRefreshStore(gormDB, logger)
-	token := auth.NewToken(gormRefreshStore)
-	jwtDriver := auth.NewJWTDriver(configConfig, token)
+	gormDenyStore := auth.NewGormDenyStore(gormDB, logger)
+	token := auth.NewToken(gormRefreshStore, gormDenyStore, gormTxManager, broker)
+	rbacGormStore := rbac.NewStore(gormDB, logger)
+	rbacService := rbac.NewService(rbacGormStore, gormTxManager, logger)
+	keySet, err := auth.NewKeySet(configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	jwtDriver := auth.NewJWTDriver(configConfig, keySet, token, userService, rbacService)
 	auth2Manager, err := AuthProvider(dbUserProvider, jwtDriver)
 	if err != nil {
 		return nil, err
@@ -60,19 +77,61 @@ func InitApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	authHandler := http.NewAuthHandler(auth2Manager, validator)
-	dashboardGormStore := dashboard.NewStore(gormDB, logger)
-	dashboardService := dashboard.NewService(dashboardGormStore, logger)
+	inviter, err := user.NewInviter(configConfig, userGormStore, userService, gormTxManager, broker, logger)
+	if err != nil {
+		return nil, err
+	}
+	resetter, err := user.NewResetter(configConfig, userGormStore, userService, gormTxManager, broker, logger)
+	if err != nil {
+		return nil, err
+	}
+	resetPassword := app.NewResetPassword(resetter, token, gormTxManager, logger)
+	twoFactorAuth, err := user.NewTwoFactorAuth(configConfig, userGormStore, hashingManager, gormTxManager, logger)
+	if err != nil {
+		return nil, err
+	}
+	authHandler := http.NewAuthHandler(configConfig, auth2Manager, jwtDriver, userService, inviter, resetter, resetPassword, twoFactorAuth, validator)
+	customfieldGormStore := customfield.NewStore(gormDB, logger)
+	customfieldService := customfield.NewService(customfieldGormStore, logger)
+	dashboardService := dashboard.NewService(cachedStore, service, logger)
 	dashboardHandler := http.NewDashboardHandler(dashboardService, logger)
-	userHandler := http.NewUserHandler(userService, logger)
-	customerHandler := http.NewCustomerHandler(service, validator, logger)
+	gormLayoutStore := dashboard.NewLayoutStore(gormDB, logger)
+	layoutService := dashboard.NewLayoutService(gormLayoutStore, logger)
+	tenantGormStore := tenant.NewStore(gormDB, logger)
+	tenantService := tenant.NewService(tenantGormStore, gormTxManager, logger)
+	updateUser := app.NewUpdateUser(userService, token, gormTxManager, logger)
+	deleteUser := app.NewDeleteUser(userService, rbacService, tenantService, twoFactorAuth, token, gormTxManager, logger)
+	userHandler := http.NewUserHandler(userService, inviter, updateUser, deleteUser, validator, logger)
+	storageManager := storage.NewManager(configConfig)
+	uploadAvatar := app.NewUploadAvatar(service, storageManager, logger)
+	customerHandler := http.NewCustomerHandler(service, verifier, uploadAvatar, customfieldService, validator, logger)
 	invoiceGormStore := invoice.NewStore(gormDB, logger)
-	invoiceService := invoice.NewService(invoiceGormStore, logger)
-	gormTxManager := db.NewTxManager(gormDB)
+	invoiceService := invoice.NewService(invoiceGormStore, broker, logger)
+	feed := dashboard.NewFeed(dashboardService, invoiceService, logger)
+	registerInitializer := registry.RegisterAll(configConfig, broker, service, verifier, cachedStore, feed, userService, inviter, resetter, manager, logger)
 	createInvoice := app.NewCreateInvoice(service, invoiceService, gormTxManager, logger)
-	invoiceHandler := http.NewInvoiceHandler(invoiceService, createInvoice, validator, logger)
-	routeInitializer := http.SetupFiberRoutes(fiberServer, auth2Manager, authHandler, dashboardHandler, userHandler, customerHandler, invoiceHandler)
-	bootstrapApp, err := AppProvider(configConfig, gormDB, logger, fiberServer, registerInitializer, routeInitializer)
+	invoiceHandler := http.NewInvoiceHandler(invoiceService, createInvoice, customfieldService, validator, logger)
+	exportCustomerData := app.NewExportCustomerData(service, invoiceService, storageManager, logger)
+	eraseCustomer := app.NewEraseCustomer(service, storageManager, gormTxManager, logger)
+	customerDataHandler := http.NewCustomerDataHandler(exportCustomerData, eraseCustomer, validator, logger)
+	customerStatement := app.NewCustomerStatement(configConfig, service, invoiceService, manager, logger)
+	statementHandler := http.NewStatementHandler(customerStatement, logger)
+	customFieldHandler := http.NewCustomFieldHandler(customfieldService, validator, logger)
+	layoutHandler := http.NewLayoutHandler(layoutService, validator, logger)
+	streamHandler := http.NewStreamHandler(feed, fiberServer, auth2Manager, tenantService, logger)
+	roleHandler := http.NewRoleHandler(rbacService, userService, validator, logger)
+	sessionHandler := http.NewSessionHandler(token, jwtDriver, userService, logger)
+	twoFactorHandler := http.NewTwoFactorHandler(twoFactorAuth, validator, logger)
+	tenantHandler := http.NewTenantHandler(tenantService, userService, validator, logger)
+	routeInitializer, err := http.SetupFiberRoutes(fiberServer, auth2Manager, authHandler, dashboardHandler, userHandler, customerHandler, customerDataHandler, statementHandler, invoiceHandler, customFieldHandler, streamHandler, layoutHandler, roleHandler, sessionHandler, twoFactorHandler, tenantHandler, tenantService)
+	if err != nil {
+		return nil, err
+	}
+	rbacInitializer, err := RBACProvider(configConfig, rbacService, userService, logger)
+	if err != nil {
+		return nil, err
+	}
+	bootstrapApp, err := AppProvider(configConfig, gormDB, logger, fiberServer, registerInitializer, routeInitializer, rbacInitializer)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/gelozr/go-dash/internal/signing"
 )
 
 type Env = int
@@ -19,7 +21,15 @@ type Config struct {
 	AppEnv      Env
 	AppPort     string `mapstructure:"APP_PORT"`
 	AppTimezone string `mapstructure:"APP_TIMEZONE"`
-	AppDebug    bool   `mapstructure:"APP_DEBUG"`
+	// Location is APP_TIMEZONE loaded; the zone of the database and the
+	// default for requests that do not choose one.
+	Location *time.Location `mapstructure:"-"`
+	AppDebug bool           `mapstructure:"APP_DEBUG"`
+	AppURL   string         `mapstructure:"APP_URL"`
+	// FrontendURL is where emailed links needing a form, such as
+	// invitations, point; defaults to APP_URL.
+	FrontendURL string `mapstructure:"FRONTEND_URL"`
+	AppKey      string `mapstructure:"APP_KEY"`
 
 	DBHost string `mapstructure:"DB_HOST"`
 	DBPort int    `mapstructure:"DB_PORT"`
@@ -34,7 +44,20 @@ type Config struct {
 	LogPath   string `mapstructure:"LOG_PATH"`   // "./logs/app.log" | "/var/log/<app_name>/app.log"
 	LogOutput string `mapstructure:"LOG_OUTPUT"` // "stdout" (default) | "file"
 
-	JWTHmacKey string `mapstructure:"JWT_HMAC_KEY"`
+	// JWTKeysDir holds the access token signing keys, RSA or Ed25519 PEM
+	// files named <kid>.pem, optionally scheduled with an Activates-At
+	// header. Empty signs with a throwaway key when local.
+	JWTKeysDir  string `mapstructure:"JWT_KEYS_DIR"`
+	JWTIssuer   string `mapstructure:"JWT_ISSUER"`   // defaults to APP_URL
+	JWTAudience string `mapstructure:"JWT_AUDIENCE"` // defaults to APP_URL
+
+	TOTPIssuer string `mapstructure:"TOTP_ISSUER"` // shown in authenticator apps
+
+	AdminEmail string `mapstructure:"ADMIN_EMAIL"` // granted the admin role on startup
+
+	RegistrationEnabled bool          `mapstructure:"REGISTRATION_ENABLED"` // allows POST /api/auth/register
+	UserInviteTTL       time.Duration `mapstructure:"USER_INVITE_TTL"`      // e.g. "72h"
+	PasswordResetTTL    time.Duration `mapstructure:"PASSWORD_RESET_TTL"`   // e.g. "1h"
 
 	MailDriver        string `mapstructure:"MAIL_DRIVER"`
 	MailHost          string `mapstructure:"MAIL_HOST"`
@@ -42,6 +65,25 @@ type Config struct {
 	MailUser          string `mapstructure:"MAIL_USER"`
 	MailPass          string `mapstructure:"MAIL_PASS"`
 	MailSkipTLSVerify bool   `mapstructure:"MAIL_SKIP_TLS_VERIFY"`
+	MailFromAddress   string `mapstructure:"MAIL_FROM_ADDRESS"`
+	MailFromName      string `mapstructure:"MAIL_FROM_NAME"`
+
+	StorageDriver    string `mapstructure:"STORAGE_DRIVER"`     // "local" (default) | "s3"
+	StorageLocalPath string `mapstructure:"STORAGE_LOCAL_PATH"` // e.g. "./storage"
+	StorageLocalURL  string `mapstructure:"STORAGE_LOCAL_URL"`  // e.g. "http://localhost:8000/storage"
+
+	S3Endpoint     string `mapstructure:"S3_ENDPOINT"`
+	S3Region       string `mapstructure:"S3_REGION"`
+	S3Bucket       string `mapstructure:"S3_BUCKET"`
+	S3AccessKey    string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey    string `mapstructure:"S3_SECRET_KEY"`
+	S3UsePathStyle bool   `mapstructure:"S3_USE_PATH_STYLE"` // true for MinIO and other local stand-ins
+	S3PublicURL    string `mapstructure:"S3_PUBLIC_URL"`
+
+	CustomerVerifyTTL            time.Duration `mapstructure:"CUSTOMER_VERIFY_TTL"`             // e.g. "24h"
+	CustomerVerifyResendInterval time.Duration `mapstructure:"CUSTOMER_VERIFY_RESEND_INTERVAL"` // e.g. "1m"
+
+	DashboardCacheTTL time.Duration `mapstructure:"DASHBOARD_CACHE_TTL"` // e.g. "1m"; negative disables caching
 }
 
 func Load() (*Config, error) {
@@ -72,12 +114,27 @@ func Load() (*Config, error) {
 		cfg.AppEnv = Local
 	}
 
-	// set app timezone
+	// signs emailed links and login challenges; a guessable key forges them
+	if len(cfg.AppKey) < signing.MinKeyLen {
+		return nil, fmt.Errorf("APP_KEY must be at least %d characters, e.g. from `openssl rand -base64 32`", signing.MinKeyLen)
+	}
+
+	// app timezone; requests may choose their own, so time.Local is left alone
 	loc, err := time.LoadLocation(cfg.AppTimezone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load timezone: %w", err)
 	}
-	time.Local = loc
+	cfg.Location = loc
+
+	if cfg.FrontendURL == "" {
+		cfg.FrontendURL = cfg.AppURL
+	}
+	if cfg.JWTIssuer == "" {
+		cfg.JWTIssuer = cfg.AppURL
+	}
+	if cfg.JWTAudience == "" {
+		cfg.JWTAudience = cfg.AppURL
+	}
 
 	return &cfg, nil
 }
@@ -1,12 +1,47 @@
 package customer
 
 import (
+	"time"
+
 	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customfield"
 )
 
 type Customer struct {
-	ID       uuid.UUID
-	Name     string
-	Email    string
-	ImageURL *string
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	Name       string
+	Email      string
+	ImageURL   *string
+	VerifiedAt *time.Time
+	ErasedAt   *time.Time
+
+	CustomFields customfield.Values
+}
+
+func (c Customer) IsVerified() bool {
+	return c.VerifiedAt != nil
+}
+
+func (c Customer) IsErased() bool {
+	return c.ErasedAt != nil
+}
+
+// Erasure records a right-to-erasure request fulfilled for a customer.
+type Erasure struct {
+	ID          uuid.UUID
+	CustomerID  uuid.UUID
+	RequestedBy uuid.UUID
+	Reason      string
+	CreatedAt   time.Time
+}
+
+// StatementEmail records a statement of account emailed to a customer.
+type StatementEmail struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	From       time.Time
+	To         time.Time
+	CreatedAt  time.Time
 }
@@ -7,3 +7,19 @@ import (
 type Created struct {
 	ID uuid.UUID
 }
+
+// VerificationRequested is published when a customer asks for a new
+// verification email; VerificationID is the verification to send.
+type VerificationRequested struct {
+	ID             uuid.UUID
+	VerificationID uuid.UUID
+}
+
+type Verified struct {
+	ID uuid.UUID
+}
+
+type Erased struct {
+	ID          uuid.UUID
+	RequestedBy uuid.UUID
+}
@@ -9,16 +9,24 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/logger"
 )
 
 type customerModel struct {
 	ID       uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	TenantID uuid.UUID `gorm:"type:char(36);not nullable;uniqueIndex:idx_tenant_email"`
 	Name     string    `gorm:"type:varchar(255);not nullable"`
-	Email    string    `gorm:"type:varchar(255);not nullable;unique"`
+	Email    string    `gorm:"type:varchar(255);not nullable;uniqueIndex:idx_tenant_email"`
 	ImageURL *string   `gorm:"type:varchar(255)"`
+
+	VerifiedAt *time.Time
+	ErasedAt   *time.Time
+
+	CustomFields customfield.Values `gorm:"type:json"`
 }
 
 func (c *customerModel) BeforeCreate(*gorm.DB) (err error) {
@@ -35,19 +43,95 @@ func (c *customerModel) TableName() string {
 
 func toModel(c Customer) customerModel {
 	return customerModel{
-		ID:       c.ID,
-		Name:     c.Name,
-		Email:    c.Email,
-		ImageURL: c.ImageURL,
+		ID:         c.ID,
+		TenantID:   c.TenantID,
+		Name:       c.Name,
+		Email:      c.Email,
+		ImageURL:   c.ImageURL,
+		VerifiedAt: c.VerifiedAt,
+		ErasedAt:   c.ErasedAt,
+
+		CustomFields: c.CustomFields,
 	}
 }
 
 func toEntity(c customerModel) Customer {
 	return Customer{
-		ID:       c.ID,
-		Name:     c.Name,
-		Email:    c.Email,
-		ImageURL: c.ImageURL,
+		ID:         c.ID,
+		TenantID:   c.TenantID,
+		Name:       c.Name,
+		Email:      c.Email,
+		ImageURL:   c.ImageURL,
+		VerifiedAt: c.VerifiedAt,
+		ErasedAt:   c.ErasedAt,
+
+		CustomFields: c.CustomFields,
+	}
+}
+
+type verificationModel struct {
+	ID         uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	CustomerID uuid.UUID `gorm:"type:char(36);not nullable;index"`
+	ExpiresAt  time.Time `gorm:"not nullable"`
+	UsedAt     *time.Time
+	CreatedAt  time.Time `gorm:"not nullable"`
+}
+
+func (*verificationModel) TableName() string {
+	return "customer_verifications"
+}
+
+func toVerificationEntity(v verificationModel) Verification {
+	return Verification{
+		ID:         v.ID,
+		CustomerID: v.CustomerID,
+		ExpiresAt:  v.ExpiresAt,
+		UsedAt:     v.UsedAt,
+		CreatedAt:  v.CreatedAt,
+	}
+}
+
+type erasureModel struct {
+	ID          uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	CustomerID  uuid.UUID `gorm:"type:char(36);not nullable;index"`
+	RequestedBy uuid.UUID `gorm:"type:char(36);not nullable"`
+	Reason      string    `gorm:"type:text"`
+	CreatedAt   time.Time `gorm:"not nullable"`
+}
+
+func (*erasureModel) TableName() string {
+	return "customer_erasures"
+}
+
+func toErasureEntity(e erasureModel) Erasure {
+	return Erasure{
+		ID:          e.ID,
+		CustomerID:  e.CustomerID,
+		RequestedBy: e.RequestedBy,
+		Reason:      e.Reason,
+		CreatedAt:   e.CreatedAt,
+	}
+}
+
+type statementEmailModel struct {
+	ID         uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	CustomerID uuid.UUID `gorm:"type:char(36);not nullable;index"`
+	PeriodFrom time.Time `gorm:"not nullable"`
+	PeriodTo   time.Time `gorm:"not nullable"`
+	CreatedAt  time.Time `gorm:"not nullable"`
+}
+
+func (*statementEmailModel) TableName() string {
+	return "customer_statement_emails"
+}
+
+func toStatementEmailEntity(e statementEmailModel) StatementEmail {
+	return StatementEmail{
+		ID:         e.ID,
+		CustomerID: e.CustomerID,
+		From:       e.PeriodFrom,
+		To:         e.PeriodTo,
+		CreatedAt:  e.CreatedAt,
 	}
 }
 
@@ -90,6 +174,26 @@ func (s *GormStore) List(ctx context.Context) ([]Customer, error) {
 	return customers, nil
 }
 
+func (s *GormStore) FindForUpdate(ctx context.Context, id uuid.UUID) (*Customer, error) {
+	var model customerModel
+
+	err := s.DB(ctx).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		First(&model, "id = ?", id).Error
+
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrCustomerNotFound
+		default:
+			return nil, fmt.Errorf("query customer for update: %w", err)
+		}
+	}
+
+	c := toEntity(model)
+	return &c, nil
+}
+
 func (s *GormStore) Find(ctx context.Context, id uuid.UUID) (*Customer, error) {
 	var model customerModel
 
@@ -140,27 +244,54 @@ func (s *GormStore) Insert(ctx context.Context, c Customer) (*Customer, error) {
 	return &c, nil
 }
 
-func (s *GormStore) SearchWithInvoiceInfo(ctx context.Context, search string) ([]WithInvoiceInfo, error) {
+func (s *GormStore) SearchWithInvoiceInfo(ctx context.Context, filter SearchFilter) ([]WithInvoiceInfo, error) {
 	var out []WithInvoiceInfo
 
 	start := time.Now()
 
-	err := s.DB(ctx).
+	join := "LEFT JOIN invoices ON customers.id = invoices.customer_id AND invoices.tenant_id = customers.tenant_id"
+	var joinArgs []any
+	if !filter.InvoicesFrom.IsZero() {
+		join += " AND invoices.date >= ?"
+		joinArgs = append(joinArgs, filter.InvoicesFrom)
+	}
+	if !filter.InvoicesTo.IsZero() {
+		join += " AND invoices.date < ?"
+		joinArgs = append(joinArgs, filter.InvoicesTo)
+	}
+
+	order := "customers.name"
+	switch filter.OrderBy {
+	case OrderByTotalPaid, OrderByTotalPending, OrderByInvoiceCount:
+		order = string(filter.OrderBy) + " DESC, customers.name"
+	}
+
+	tx := s.DB(ctx).
 		Model(&customerModel{}).
 		Select(`
             customers.id,
             customers.name,
             customers.email,
             customers.image_url,
+            customers.custom_fields,
             COUNT(invoices.id) AS total_invoices,
             SUM(CASE WHEN invoices.status = 'pending' THEN invoices.amount ELSE 0 END) AS total_pending,
             SUM(CASE WHEN invoices.status = 'paid'    THEN invoices.amount ELSE 0 END) AS total_paid
         `).
-		Joins("LEFT JOIN invoices ON customers.id = invoices.customer_id").
-		Where("customers.name LIKE @s OR customers.email LIKE @s", sql.Named("s", "%"+search+"%")).
-		Group("customers.id, customers.name, customers.email, customers.image_url").
-		Order("customers.name").
-		Scan(&out).Error
+		Joins(join, joinArgs...).
+		Scopes(customfield.Scope("customers.custom_fields", filter.CustomFields)).
+		Group("customers.id, customers.name, customers.email, customers.image_url, customers.custom_fields").
+		Order(order)
+
+	if filter.Text != "" {
+		tx = tx.Where("customers.name LIKE @s OR customers.email LIKE @s", sql.Named("s", "%"+filter.Text+"%"))
+	}
+
+	if filter.Limit > 0 {
+		tx = tx.Limit(filter.Limit)
+	}
+
+	err := tx.Scan(&out).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("customer with invoice info query: %w", err)
@@ -170,3 +301,216 @@ func (s *GormStore) SearchWithInvoiceInfo(ctx context.Context, search string) ([
 
 	return out, nil
 }
+
+func (s *GormStore) MarkVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := s.DB(ctx).
+		Model(&customerModel{}).
+		Where("id = ? AND verified_at IS NULL", id).
+		Update("verified_at", at).Error
+
+	if err != nil {
+		return fmt.Errorf("mark customer verified: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) UpdateImageURL(ctx context.Context, id uuid.UUID, imageURL *string) error {
+	err := s.DB(ctx).
+		Model(&customerModel{}).
+		Where("id = ?", id).
+		Update("image_url", imageURL).Error
+
+	if err != nil {
+		return fmt.Errorf("update customer image url: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) Anonymise(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := s.DB(ctx).
+		Model(&customerModel{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"name":          "Erased customer",
+			"email":         fmt.Sprintf("erased+%s@invalid", id),
+			"image_url":     nil,
+			"custom_fields": nil,
+			"erased_at":     at,
+		}).Error
+
+	if err != nil {
+		return fmt.Errorf("anonymise customer: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) UpdateCustomFields(ctx context.Context, id uuid.UUID, values customfield.Values) error {
+	err := s.DB(ctx).
+		Model(&customerModel{}).
+		Where("id = ?", id).
+		Update("custom_fields", values).Error
+
+	if err != nil {
+		return fmt.Errorf("update customer custom fields: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) InsertErasure(ctx context.Context, e Erasure) (*Erasure, error) {
+	model := erasureModel{
+		ID:          e.ID,
+		CustomerID:  e.CustomerID,
+		RequestedBy: e.RequestedBy,
+		Reason:      e.Reason,
+		CreatedAt:   e.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store customer erasure: %w", err)
+	}
+
+	e = toErasureEntity(model)
+	return &e, nil
+}
+
+func (s *GormStore) ListErasures(ctx context.Context, customerID uuid.UUID) ([]Erasure, error) {
+	var models []erasureModel
+
+	if err := s.DB(ctx).Where("customer_id = ?", customerID).Order("created_at").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query customer erasures: %w", err)
+	}
+
+	out := make([]Erasure, len(models))
+	for i, m := range models {
+		out[i] = toErasureEntity(m)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) InsertStatementEmail(ctx context.Context, e StatementEmail) (*StatementEmail, error) {
+	model := statementEmailModel{
+		ID:         e.ID,
+		CustomerID: e.CustomerID,
+		PeriodFrom: e.From,
+		PeriodTo:   e.To,
+		CreatedAt:  e.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store customer statement email: %w", err)
+	}
+
+	e = toStatementEmailEntity(model)
+	return &e, nil
+}
+
+func (s *GormStore) ListStatementEmails(ctx context.Context, customerID uuid.UUID) ([]StatementEmail, error) {
+	var models []statementEmailModel
+
+	if err := s.DB(ctx).Where("customer_id = ?", customerID).Order("created_at").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query customer statement emails: %w", err)
+	}
+
+	out := make([]StatementEmail, len(models))
+	for i, m := range models {
+		out[i] = toStatementEmailEntity(m)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) InsertVerification(ctx context.Context, v Verification) (*Verification, error) {
+	model := verificationModel{
+		ID:         v.ID,
+		CustomerID: v.CustomerID,
+		ExpiresAt:  v.ExpiresAt,
+		UsedAt:     v.UsedAt,
+		CreatedAt:  v.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store customer verification: %w", err)
+	}
+
+	v = toVerificationEntity(model)
+	return &v, nil
+}
+
+func (s *GormStore) FindVerification(ctx context.Context, id uuid.UUID) (*Verification, error) {
+	var model verificationModel
+
+	if err := s.DB(ctx).First(&model, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrVerificationNotFound
+		default:
+			return nil, fmt.Errorf("query customer verification: %w", err)
+		}
+	}
+
+	v := toVerificationEntity(model)
+	return &v, nil
+}
+
+func (s *GormStore) ListVerifications(ctx context.Context, customerID uuid.UUID) ([]Verification, error) {
+	var models []verificationModel
+
+	if err := s.DB(ctx).Where("customer_id = ?", customerID).Order("created_at").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query customer verifications: %w", err)
+	}
+
+	out := make([]Verification, len(models))
+	for i, m := range models {
+		out[i] = toVerificationEntity(m)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) DeleteVerifications(ctx context.Context, customerID uuid.UUID) error {
+	if err := s.DB(ctx).Where("customer_id = ?", customerID).Delete(&verificationModel{}).Error; err != nil {
+		return fmt.Errorf("delete customer verifications: %w", err)
+	}
+	return nil
+}
+
+func (s *GormStore) LatestVerification(ctx context.Context, customerID uuid.UUID) (*Verification, error) {
+	var model verificationModel
+
+	err := s.DB(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		First(&model).Error
+
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrVerificationNotFound
+		default:
+			return nil, fmt.Errorf("query latest customer verification: %w", err)
+		}
+	}
+
+	v := toVerificationEntity(model)
+	return &v, nil
+}
+
+// UseVerification marks the verification as used only if it has not been used yet,
+// so two concurrent requests with the same token cannot both succeed.
+func (s *GormStore) UseVerification(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := s.DB(ctx).
+		Model(&verificationModel{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("use customer verification: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
@@ -3,9 +3,12 @@ package customer
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/customfield"
+	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/event"
 	"github.com/gelozr/go-dash/internal/logger"
 )
@@ -77,11 +80,135 @@ func (s *Service) Create(ctx context.Context, c Customer) (*Customer, error) {
 	return cust, nil
 }
 
-func (s *Service) SearchWithInvoiceInfo(ctx context.Context, search string) ([]WithInvoiceInfo, error) {
-	result, err := s.store.SearchWithInvoiceInfo(ctx, search)
+func (s *Service) SetImageURL(ctx context.Context, id uuid.UUID, imageURL *string) (*Customer, error) {
+	if err := s.store.UpdateImageURL(ctx, id, imageURL); err != nil {
+		return nil, fmt.Errorf("update image url: %w", err)
+	}
+
+	c, err := s.store.Find(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find customer: %w", err)
+	}
+
+	return c, nil
+}
+
+// SetCustomFields replaces the customer's custom field values. Values must
+// already be validated by customfield.Service. Erased customers are not
+// updated.
+func (s *Service) SetCustomFields(ctx context.Context, id uuid.UUID, values customfield.Values) (*Customer, error) {
+	c, err := s.store.Find(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find customer: %w", err)
+	}
+
+	if c.IsErased() {
+		return nil, ErrCustomerErased
+	}
+
+	if err = s.store.UpdateCustomFields(ctx, id, values); err != nil {
+		return nil, fmt.Errorf("update custom fields: %w", err)
+	}
+
+	c, err = s.store.Find(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find customer: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Service) SearchWithInvoiceInfo(ctx context.Context, filter SearchFilter) ([]WithInvoiceInfo, error) {
+	result, err := s.store.SearchWithInvoiceInfo(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("search with invoice totals: %w", err)
 	}
 
 	return result, nil
 }
+
+func (s *Service) ListVerifications(ctx context.Context, id uuid.UUID) ([]Verification, error) {
+	out, err := s.store.ListVerifications(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list verifications: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Service) ListErasures(ctx context.Context, id uuid.UUID) ([]Erasure, error) {
+	out, err := s.store.ListErasures(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list erasures: %w", err)
+	}
+	return out, nil
+}
+
+// RecordStatementEmail records that a statement of the period was emailed
+// to the customer.
+func (s *Service) RecordStatementEmail(ctx context.Context, id uuid.UUID, from, to time.Time) (*StatementEmail, error) {
+	e, err := s.store.InsertStatementEmail(ctx, StatementEmail{
+		ID:         uuid.New(),
+		CustomerID: id,
+		From:       from,
+		To:         to,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert statement email: %w", err)
+	}
+	return e, nil
+}
+
+func (s *Service) ListStatementEmails(ctx context.Context, id uuid.UUID) ([]StatementEmail, error) {
+	out, err := s.store.ListStatementEmails(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list statement emails: %w", err)
+	}
+	return out, nil
+}
+
+// Erase anonymises the customer's personal fields and records who requested it.
+// Invoices are left untouched so financial records can be retained.
+// Erased is published once the transaction of ctx, if any, commits.
+func (s *Service) Erase(ctx context.Context, id, requestedBy uuid.UUID, reason string) (*Erasure, error) {
+	// locked so concurrent erasures of the customer run one after the other
+	c, err := s.store.FindForUpdate(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find customer: %w", err)
+	}
+
+	if c.IsErased() {
+		return nil, ErrCustomerErased
+	}
+
+	now := time.Now()
+
+	if err = s.store.Anonymise(ctx, id, now); err != nil {
+		return nil, fmt.Errorf("anonymise customer: %w", err)
+	}
+
+	if err = s.store.DeleteVerifications(ctx, id); err != nil {
+		return nil, fmt.Errorf("delete verifications: %w", err)
+	}
+
+	e, err := s.store.InsertErasure(ctx, Erasure{
+		ID:          uuid.New(),
+		CustomerID:  id,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert erasure: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "customer erased", "customer_id", id, "requested_by", requestedBy)
+
+	db.AfterCommit(ctx, func(ctx context.Context) {
+		if err := s.event.Publish(ctx, Erased{ID: id, RequestedBy: requestedBy}); err != nil {
+			s.logger.ErrorContext(ctx, "publish customer erased", "customer_id", id, "error", err.Error())
+		}
+	})
+
+	return e, nil
+}
@@ -3,20 +3,67 @@ package customer
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customfield"
 )
 
 var ErrCustomerNotFound = errors.New("customer not found")
 var ErrEmailAlreadyTaken = errors.New("email already exists")
+var ErrVerificationNotFound = errors.New("customer verification not found")
+var ErrCustomerErased = errors.New("customer is erased")
 
 type Store interface {
 	List(ctx context.Context) ([]Customer, error)
 	Find(ctx context.Context, id uuid.UUID) (*Customer, error)
+	// FindForUpdate finds the customer and locks its row until the
+	// transaction of ctx ends.
+	FindForUpdate(ctx context.Context, id uuid.UUID) (*Customer, error)
 	Exists(ctx context.Context, id uuid.UUID) (bool, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	Insert(ctx context.Context, c Customer) (*Customer, error)
-	SearchWithInvoiceInfo(ctx context.Context, search string) ([]WithInvoiceInfo, error)
+	SearchWithInvoiceInfo(ctx context.Context, filter SearchFilter) ([]WithInvoiceInfo, error)
+	MarkVerified(ctx context.Context, id uuid.UUID, at time.Time) error
+	UpdateImageURL(ctx context.Context, id uuid.UUID, imageURL *string) error
+	UpdateCustomFields(ctx context.Context, id uuid.UUID, values customfield.Values) error
+	Anonymise(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	InsertErasure(ctx context.Context, e Erasure) (*Erasure, error)
+	ListErasures(ctx context.Context, customerID uuid.UUID) ([]Erasure, error)
+
+	InsertStatementEmail(ctx context.Context, e StatementEmail) (*StatementEmail, error)
+	ListStatementEmails(ctx context.Context, customerID uuid.UUID) ([]StatementEmail, error)
+
+	InsertVerification(ctx context.Context, v Verification) (*Verification, error)
+	FindVerification(ctx context.Context, id uuid.UUID) (*Verification, error)
+	ListVerifications(ctx context.Context, customerID uuid.UUID) ([]Verification, error)
+	DeleteVerifications(ctx context.Context, customerID uuid.UUID) error
+	LatestVerification(ctx context.Context, customerID uuid.UUID) (*Verification, error)
+	UseVerification(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+}
+
+type InfoOrder string
+
+const (
+	OrderByName         InfoOrder = ""
+	OrderByTotalPaid    InfoOrder = "total_paid"
+	OrderByTotalPending InfoOrder = "total_pending"
+	OrderByInvoiceCount InfoOrder = "total_invoices"
+)
+
+type SearchFilter struct {
+	Text         string
+	CustomFields customfield.Filters
+
+	// InvoicesFrom and InvoicesTo limit the aggregated invoices to those
+	// dated in [InvoicesFrom, InvoicesTo). A zero bound is unbounded.
+	InvoicesFrom, InvoicesTo time.Time
+
+	// OrderBy sorts by name, or descending by an invoice total.
+	OrderBy InfoOrder
+	Limit   int
 }
 
 type WithInvoiceInfo struct {
@@ -24,6 +71,7 @@ type WithInvoiceInfo struct {
 	Name          string
 	Email         string
 	ImageURL      *string
+	CustomFields  customfield.Values
 	TotalInvoices int64
 	TotalPending  float64
 	TotalPaid     float64
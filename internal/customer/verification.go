@@ -0,0 +1,224 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/event"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/signing"
+	"github.com/gelozr/go-dash/internal/tenant"
+)
+
+var (
+	ErrVerificationTokenInvalid = errors.New("verification token is invalid")
+	ErrVerificationTokenExpired = errors.New("verification token is expired")
+	ErrVerificationTokenUsed    = errors.New("verification token is used")
+	ErrVerificationThrottled    = errors.New("verification recently sent")
+	ErrAlreadyVerified          = errors.New("customer already verified")
+)
+
+const (
+	defaultVerifyTTL            = 24 * time.Hour
+	defaultVerifyResendInterval = time.Minute
+)
+
+type Verification struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+	CreatedAt  time.Time
+}
+
+type Verifier struct {
+	store          Store
+	txm            db.TxManager
+	event          event.Publisher
+	signer         *signing.Signer
+	ttl            time.Duration
+	resendInterval time.Duration
+	logger         logger.Logger
+}
+
+func NewVerifier(
+	cfg *config.Config,
+	store Store,
+	txm db.TxManager,
+	evt event.Publisher,
+	log logger.Logger,
+) (*Verifier, error) {
+	signer, err := signing.NewSigner([]byte(cfg.AppKey), "customer-verification")
+	if err != nil {
+		return nil, fmt.Errorf("customer verification signer: %w", err)
+	}
+
+	ttl := cfg.CustomerVerifyTTL
+	if ttl <= 0 {
+		ttl = defaultVerifyTTL
+	}
+
+	resendInterval := cfg.CustomerVerifyResendInterval
+	if resendInterval <= 0 {
+		resendInterval = defaultVerifyResendInterval
+	}
+
+	return &Verifier{
+		store:          store,
+		txm:            txm,
+		event:          evt,
+		signer:         signer,
+		ttl:            ttl,
+		resendInterval: resendInterval,
+		logger:         log.With("component", "service.customer.verifier"),
+	}, nil
+}
+
+// Issue creates a new single-use verification for the customer and returns its signed token.
+func (v *Verifier) Issue(ctx context.Context, customerID uuid.UUID) (string, error) {
+	ver, err := v.insert(ctx, customerID)
+	if err != nil {
+		return "", err
+	}
+
+	return v.Token(ver), nil
+}
+
+func (v *Verifier) Get(ctx context.Context, id uuid.UUID) (*Verification, error) {
+	ver, err := v.store.FindVerification(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find verification: %w", err)
+	}
+	return ver, nil
+}
+
+// Token returns the signed token of the verification.
+func (v *Verifier) Token(ver *Verification) string {
+	return v.signer.Sign(ver.ID, ver.ExpiresAt)
+}
+
+// Resend records a new verification and requests its email, unless one was
+// created within the resend interval. The customer row is locked while
+// checking, so concurrent resends cannot all pass the throttle.
+func (v *Verifier) Resend(ctx context.Context, customerID uuid.UUID) error {
+	var ver *Verification
+
+	txErr := v.txm.Do(ctx, func(txCtx context.Context) error {
+		cust, err := v.store.FindForUpdate(txCtx, customerID)
+		if err != nil {
+			return fmt.Errorf("find customer: %w", err)
+		}
+
+		if cust.IsErased() {
+			return ErrCustomerErased
+		}
+
+		if cust.IsVerified() {
+			return ErrAlreadyVerified
+		}
+
+		last, err := v.store.LatestVerification(txCtx, customerID)
+		if err != nil && !errors.Is(err, ErrVerificationNotFound) {
+			return fmt.Errorf("latest verification: %w", err)
+		}
+
+		if last != nil && time.Since(last.CreatedAt) < v.resendInterval {
+			return ErrVerificationThrottled
+		}
+
+		ver, err = v.insert(txCtx, customerID)
+		return err
+	})
+
+	if txErr != nil {
+		if errors.Is(txErr, ErrVerificationThrottled) {
+			v.logger.WarnContext(ctx, "verification resend throttled", "customer_id", customerID)
+		}
+		return fmt.Errorf("resend verification tx: %w", txErr)
+	}
+
+	if err := v.event.Publish(ctx, VerificationRequested{ID: customerID, VerificationID: ver.ID}); err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
+
+	return nil
+}
+
+func (v *Verifier) insert(ctx context.Context, customerID uuid.UUID) (*Verification, error) {
+	now := time.Now()
+
+	ver, err := v.store.InsertVerification(ctx, Verification{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		ExpiresAt:  now.Add(v.ttl),
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert verification: %w", err)
+	}
+
+	return ver, nil
+}
+
+// Verify consumes the token and marks its customer as verified.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Customer, error) {
+	id, exp, err := v.signer.Parse(token)
+	if err != nil {
+		return nil, ErrVerificationTokenInvalid
+	}
+
+	if time.Now().After(exp) {
+		return nil, ErrVerificationTokenExpired
+	}
+
+	var cust *Customer
+
+	// the signed token names the customer, whichever tenant it belongs to
+	txErr := v.txm.Do(tenant.WithoutScope(ctx), func(txCtx context.Context) error {
+		ver, err := v.store.FindVerification(txCtx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrVerificationNotFound):
+				return ErrVerificationTokenInvalid
+			default:
+				return fmt.Errorf("find verification: %w", err)
+			}
+		}
+
+		now := time.Now()
+
+		used, err := v.store.UseVerification(txCtx, ver.ID, now)
+		if err != nil {
+			return fmt.Errorf("use verification: %w", err)
+		}
+		if !used {
+			return ErrVerificationTokenUsed
+		}
+
+		if err = v.store.MarkVerified(txCtx, ver.CustomerID, now); err != nil {
+			return fmt.Errorf("mark verified: %w", err)
+		}
+
+		if cust, err = v.store.Find(txCtx, ver.CustomerID); err != nil {
+			return fmt.Errorf("find customer: %w", err)
+		}
+
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, fmt.Errorf("verify customer tx: %w", txErr)
+	}
+
+	if err = v.event.Publish(tenant.WithID(ctx, cust.TenantID), Verified{ID: cust.ID}); err != nil {
+		return nil, fmt.Errorf("publish event: %w", err)
+	}
+
+	return cust, nil
+}
@@ -0,0 +1,94 @@
+package customfield
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Entity string
+
+const (
+	EntityCustomer Entity = "customer"
+	EntityInvoice  Entity = "invoice"
+)
+
+func (e Entity) Valid() bool {
+	return e == EntityCustomer || e == EntityInvoice
+}
+
+type Type string
+
+const (
+	TypeText    Type = "text"
+	TypeNumber  Type = "number"
+	TypeBoolean Type = "boolean"
+	TypeDate    Type = "date" // YYYY-MM-DD
+	TypeEnum    Type = "enum"
+)
+
+func (t Type) Valid() bool {
+	switch t {
+	case TypeText, TypeNumber, TypeBoolean, TypeDate, TypeEnum:
+		return true
+	}
+	return false
+}
+
+// KeyPattern restricts keys to identifiers that are safe to embed in JSON paths.
+var KeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type Definition struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	Entity    Entity
+	Key       string
+	Label     string
+	Type      Type
+	Required  bool
+	Options   []string // allowed values for TypeEnum
+	CreatedAt time.Time
+}
+
+// Values holds the custom field values of a record, keyed by definition key.
+// It is stored as a JSON column.
+type Values map[string]any
+
+// Value implements driver.Valuer for database writes.
+func (v Values) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal custom fields: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner for database reads.
+func (v *Values) Scan(src any) error {
+	var b []byte
+
+	switch s := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		b = s
+	case string:
+		b = []byte(s)
+	default:
+		return fmt.Errorf("cannot scan %T into custom field values", src)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("unmarshal custom fields: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package customfield
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type definitionModel struct {
+	ID        uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	TenantID  uuid.UUID `gorm:"type:char(36);not nullable;uniqueIndex:idx_tenant_entity_key"`
+	Entity    string    `gorm:"type:varchar(32);not nullable;uniqueIndex:idx_tenant_entity_key"`
+	Key       string    `gorm:"type:varchar(64);not nullable;uniqueIndex:idx_tenant_entity_key"`
+	Label     string    `gorm:"type:varchar(255);not nullable"`
+	Type      string    `gorm:"type:varchar(32);not nullable"`
+	Required  bool      `gorm:"not nullable"`
+	Options   string    `gorm:"type:json"`
+	CreatedAt time.Time `gorm:"not nullable"`
+}
+
+func (m *definitionModel) BeforeCreate(*gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+
+	return
+}
+
+func (*definitionModel) TableName() string {
+	return "custom_field_definitions"
+}
+
+func toModel(d Definition) (definitionModel, error) {
+	opts, err := json.Marshal(d.Options)
+	if err != nil {
+		return definitionModel{}, fmt.Errorf("marshal options: %w", err)
+	}
+
+	return definitionModel{
+		ID:        d.ID,
+		TenantID:  d.TenantID,
+		Entity:    string(d.Entity),
+		Key:       d.Key,
+		Label:     d.Label,
+		Type:      string(d.Type),
+		Required:  d.Required,
+		Options:   string(opts),
+		CreatedAt: d.CreatedAt,
+	}, nil
+}
+
+func toEntity(m definitionModel) Definition {
+	var opts []string
+	_ = json.Unmarshal([]byte(m.Options), &opts)
+
+	return Definition{
+		ID:        m.ID,
+		TenantID:  m.TenantID,
+		Entity:    Entity(m.Entity),
+		Key:       m.Key,
+		Label:     m.Label,
+		Type:      Type(m.Type),
+		Required:  m.Required,
+		Options:   opts,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+type GormStore struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+var _ Store = (*GormStore)(nil)
+
+func NewStore(db *gorm.DB, log logger.Logger) *GormStore {
+	return &GormStore{
+		db:     db,
+		logger: log.With("component", "store.gorm.customfield"),
+	}
+}
+
+func (s *GormStore) DB(ctx context.Context) *gorm.DB {
+	if gormDB, ok := db.FromCtx(ctx); ok {
+		return gormDB.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+func (s *GormStore) List(ctx context.Context, entity Entity) ([]Definition, error) {
+	var models []definitionModel
+
+	if err := s.DB(ctx).Where("entity = ?", entity).Order("created_at").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query custom field definitions: %w", err)
+	}
+
+	out := make([]Definition, len(models))
+	for i, m := range models {
+		out[i] = toEntity(m)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) Find(ctx context.Context, id uuid.UUID) (*Definition, error) {
+	var model definitionModel
+
+	if err := s.DB(ctx).First(&model, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrDefinitionNotFound
+		default:
+			return nil, fmt.Errorf("query custom field definition: %w", err)
+		}
+	}
+
+	d := toEntity(model)
+	return &d, nil
+}
+
+func (s *GormStore) ExistsByKey(ctx context.Context, entity Entity, key string) (bool, error) {
+	tx := s.DB(ctx).Model(&definitionModel{}).Where("entity = ? AND `key` = ?", entity, key)
+
+	exists, err := db.RecordExists(tx)
+	if err != nil {
+		return false, fmt.Errorf("exists by key: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (s *GormStore) Insert(ctx context.Context, d Definition) (*Definition, error) {
+	model, err := toModel(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store custom field definition: %w", err)
+	}
+
+	d = toEntity(model)
+	return &d, nil
+}
+
+func (s *GormStore) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.DB(ctx).Delete(&definitionModel{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("delete custom field definition: %w", err)
+	}
+	return nil
+}
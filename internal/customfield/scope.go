@@ -0,0 +1,32 @@
+package customfield
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Filters matches records whose custom field equals the given value, compared as text.
+type Filters map[string]string
+
+// Scope filters on the JSON column. Keys must have been checked against
+// KeyPattern (see Service.ResolveFilters) since they are embedded in the JSON path.
+func Scope(column string, filters Filters) func(db *gorm.DB) *gorm.DB {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return func(db *gorm.DB) *gorm.DB {
+		for _, k := range keys {
+			path := fmt.Sprintf(`$."%s"`, k)
+			db = db.Where(
+				fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, ?)) = ?", column),
+				path, filters[k],
+			)
+		}
+		return db
+	}
+}
@@ -0,0 +1,232 @@
+package customfield
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+var (
+	ErrInvalidDefinition = errors.New("invalid custom field definition")
+	ErrInvalidValue      = errors.New("invalid custom field value")
+	ErrUnknownField      = errors.New("unknown custom field")
+)
+
+const maxTextLength = 1000
+
+// FieldError lists the reasons each field is invalid, by field. It wraps
+// ErrInvalidDefinition, ErrInvalidValue or ErrUnknownField.
+type FieldError struct {
+	Err    error
+	Fields map[string][]string
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FieldError) add(field, msg string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string][]string)
+	}
+	e.Fields[field] = append(e.Fields[field], msg)
+}
+
+type Service struct {
+	store  Store
+	logger logger.Logger
+}
+
+func NewService(store Store, log logger.Logger) *Service {
+	return &Service{
+		store:  store,
+		logger: log.With("component", "service.customfield"),
+	}
+}
+
+func (s *Service) List(ctx context.Context, entity Entity) ([]Definition, error) {
+	defs, err := s.store.List(ctx, entity)
+	if err != nil {
+		return nil, fmt.Errorf("list definitions: %w", err)
+	}
+	return defs, nil
+}
+
+func (s *Service) Create(ctx context.Context, d Definition) (*Definition, error) {
+	if err := checkDefinition(d); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.store.ExistsByKey(ctx, d.Entity, d.Key)
+	if err != nil {
+		return nil, fmt.Errorf("exists by key: %w", err)
+	}
+
+	if exists {
+		return nil, ErrKeyAlreadyTaken
+	}
+
+	if d.Type != TypeEnum {
+		d.Options = nil
+	}
+	d.CreatedAt = time.Now()
+
+	def, err := s.store.Insert(ctx, d)
+	if err != nil {
+		return nil, fmt.Errorf("insert definition: %w", err)
+	}
+
+	return def, nil
+}
+
+// Delete removes the definition. Existing values stay in the records but are
+// no longer validated, filterable or returned by Validate.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.store.Find(ctx, id); err != nil {
+		return fmt.Errorf("find definition: %w", err)
+	}
+
+	if err := s.store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete definition: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks that values only hold defined fields and that required
+// ones are present, and returns them normalised. The contents of the values
+// are to be checked against the returned rules, in validation.Validator
+// syntax by key. Invalid values are reported as a FieldError keyed by field
+// key.
+func (s *Service) Validate(ctx context.Context, entity Entity, values Values) (Values, map[string]string, error) {
+	defs, err := s.store.List(ctx, entity)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list definitions: %w", err)
+	}
+
+	fErr := &FieldError{Err: ErrInvalidValue}
+	known := make(map[string]bool, len(defs))
+	rules := make(map[string]string, len(defs))
+
+	for _, d := range defs {
+		known[d.Key] = true
+		rules[d.Key] = rule(d)
+
+		// "required" would reject false and 0, so presence is checked here
+		if v := values[d.Key]; d.Required && (v == nil || v == "") {
+			fErr.add(d.Key, d.Key+" is a required field")
+		}
+	}
+
+	for k := range values {
+		if !known[k] {
+			fErr.add(k, k+" is not a defined custom field")
+		}
+	}
+
+	if len(fErr.Fields) > 0 {
+		return nil, nil, fErr
+	}
+
+	out := make(Values, len(values))
+	for k, v := range values {
+		if v != nil {
+			out[k] = v
+		}
+	}
+
+	return out, rules, nil
+}
+
+// ResolveFilters keeps only filters on defined fields, so keys are safe to
+// use in Scope. Filters on other keys are reported as a FieldError wrapping
+// ErrUnknownField.
+func (s *Service) ResolveFilters(ctx context.Context, entity Entity, filters Filters) (Filters, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	defs, err := s.store.List(ctx, entity)
+	if err != nil {
+		return nil, fmt.Errorf("list definitions: %w", err)
+	}
+
+	out := make(Filters, len(filters))
+	for _, d := range defs {
+		if v, ok := filters[d.Key]; ok {
+			out[d.Key] = v
+		}
+	}
+
+	for k := range filters {
+		if _, ok := out[k]; !ok {
+			fErr := &FieldError{Err: ErrUnknownField}
+			fErr.add(k, k+" is not a defined custom field")
+			return nil, fErr
+		}
+	}
+
+	return out, nil
+}
+
+func checkDefinition(d Definition) error {
+	fErr := &FieldError{Err: ErrInvalidDefinition}
+
+	if !d.Entity.Valid() {
+		fErr.add("entity", "entity must be one of customer, invoice")
+	}
+	if !KeyPattern.MatchString(d.Key) {
+		fErr.add("key", "key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+	if !d.Type.Valid() {
+		fErr.add("type", "type must be one of text, number, boolean, date, enum")
+	}
+	if d.Type == TypeEnum {
+		if len(d.Options) == 0 {
+			fErr.add("options", "options are required for enum fields")
+		}
+		for _, o := range d.Options {
+			if o == "" || strings.ContainsAny(o, "',|") {
+				fErr.add("options", "options must be non-empty and must not contain quotes, commas or pipes")
+				break
+			}
+		}
+	}
+
+	if len(fErr.Fields) > 0 {
+		return fErr
+	}
+	return nil
+}
+
+// rule returns the validation rule of the values of the field.
+func rule(d Definition) string {
+	switch d.Type {
+	case TypeNumber:
+		return "type=number"
+	case TypeBoolean:
+		return "type=bool"
+	case TypeText:
+		return fmt.Sprintf("type=string,omitempty,max=%d", maxTextLength)
+	case TypeDate:
+		return "type=string,omitempty,datetime=" + time.DateOnly
+	case TypeEnum:
+		opts := make([]string, len(d.Options))
+		for i, o := range d.Options {
+			opts[i] = "'" + o + "'"
+		}
+		return "type=string,omitempty,oneof=" + strings.Join(opts, " ")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,21 @@
+package customfield
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDefinitionNotFound = errors.New("custom field definition not found")
+	ErrKeyAlreadyTaken    = errors.New("custom field key already exists")
+)
+
+type Store interface {
+	List(ctx context.Context, entity Entity) ([]Definition, error)
+	Find(ctx context.Context, id uuid.UUID) (*Definition, error)
+	ExistsByKey(ctx context.Context, entity Entity, key string) (bool, error)
+	Insert(ctx context.Context, d Definition) (*Definition, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,253 @@
+package dashboard
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/tenant"
+)
+
+// DefaultCacheTTL is used when DASHBOARD_CACHE_TTL is not set. A negative
+// TTL disables caching.
+const DefaultCacheTTL = time.Minute
+
+// cacheMetrics counts "<method>.hits" and "<method>.misses", served on /debug/vars.
+var cacheMetrics = expvar.NewMap("dashboard_cache")
+
+type cacheEntry struct {
+	scope   string
+	value   any
+	expires time.Time
+}
+
+// CachedStore caches the results of another Store for a TTL, keyed by the
+// tenant of the context. Invalidate drops the results of the tenant of the
+// context; it is called on domain events that change the aggregates.
+// Concurrent misses on the same key share a single query.
+// Expired entries are dropped when looked up and swept once per TTL, as keys
+// move on with the current minute.
+type CachedStore struct {
+	next   Store
+	ttl    time.Duration
+	logger logger.Logger
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	// nextSweep is when expired entries are next swept, guarded by mu
+	nextSweep time.Time
+
+	// epoch and the generation of a scope are bumped on Invalidate, guarded
+	// by mu, so loads started before it are not stored.
+	epoch       uint64
+	generations map[string]uint64
+	group       singleflight.Group
+}
+
+var _ Store = (*CachedStore)(nil)
+
+func NewCachedStore(next *GormStore, cfg *config.Config, log logger.Logger) *CachedStore {
+	ttl := cfg.DashboardCacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &CachedStore{
+		next:        next,
+		ttl:         ttl,
+		logger:      log.With("component", "store.cache.dash"),
+		entries:     make(map[string]cacheEntry),
+		generations: make(map[string]uint64),
+	}
+}
+
+// Invalidate drops the cached results of the tenant of ctx, and the
+// unscoped ones spanning all tenants. Without a tenant it drops everything.
+func (s *CachedStore) Invalidate(ctx context.Context) {
+	scope := scopeOf(ctx)
+
+	s.mu.Lock()
+	if scope == unscoped {
+		s.epoch++
+		clear(s.entries)
+	} else {
+		s.generations[scope]++
+		s.generations[unscoped]++
+		for k, e := range s.entries {
+			if e.scope == scope || e.scope == unscoped {
+				delete(s.entries, k)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	s.logger.DebugContext(ctx, "dashboard cache invalidated", "scope", scope)
+}
+
+func (s *CachedStore) GetOverview(ctx context.Context, r Range) (*Overview, error) {
+	o, err := cached(ctx, s, "overview", rangeKey(r), func(ctx context.Context) (*Overview, error) {
+		return s.next.GetOverview(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := *o
+	return &out, nil
+}
+
+func (s *CachedStore) ListDailyRevenues(ctx context.Context, from, to time.Time) ([]Revenue, error) {
+	// days depend on the zone, not only on the instants
+	key := rangeKey(Range{from, to}) + "@" + from.Location().String()
+	revs, err := cached(ctx, s, "daily_revenues", key, func(ctx context.Context) ([]Revenue, error) {
+		return s.next.ListDailyRevenues(ctx, from, to)
+	})
+	return slices.Clone(revs), err
+}
+
+func (s *CachedStore) GetInvoiceTotals(ctx context.Context, r Range) (InvoiceTotals, error) {
+	return cached(ctx, s, "invoice_totals", rangeKey(r), func(ctx context.Context) (InvoiceTotals, error) {
+		return s.next.GetInvoiceTotals(ctx, r)
+	})
+}
+
+// SumReceivables is computed at the minute of at, so callers within the
+// same minute share a result.
+func (s *CachedStore) SumReceivables(ctx context.Context, at time.Time) (float64, error) {
+	at = at.Truncate(time.Minute)
+	return cached(ctx, s, "receivables", strconv.FormatInt(at.Unix(), 10), func(ctx context.Context) (float64, error) {
+		return s.next.SumReceivables(ctx, at)
+	})
+}
+
+func (s *CachedStore) ListPaymentDelays(ctx context.Context, r Range) ([]time.Duration, error) {
+	delays, err := cached(ctx, s, "payment_delays", rangeKey(r), func(ctx context.Context) ([]time.Duration, error) {
+		return s.next.ListPaymentDelays(ctx, r)
+	})
+	return slices.Clone(delays), err
+}
+
+func (s *CachedStore) ListInvoiceAmounts(ctx context.Context, r Range) ([]InvoiceAmount, error) {
+	amounts, err := cached(ctx, s, "invoice_amounts", rangeKey(r), func(ctx context.Context) ([]InvoiceAmount, error) {
+		return s.next.ListInvoiceAmounts(ctx, r)
+	})
+	return slices.Clone(amounts), err
+}
+
+func (s *CachedStore) ListFirstInvoices(ctx context.Context, r Range) ([]FirstInvoice, error) {
+	firsts, err := cached(ctx, s, "first_invoices", rangeKey(r), func(ctx context.Context) ([]FirstInvoice, error) {
+		return s.next.ListFirstInvoices(ctx, r)
+	})
+	return slices.Clone(firsts), err
+}
+
+func (s *CachedStore) ListOpenInvoices(ctx context.Context) ([]InvoiceAmount, error) {
+	amounts, err := cached(ctx, s, "open_invoices", "", func(ctx context.Context) ([]InvoiceAmount, error) {
+		return s.next.ListOpenInvoices(ctx)
+	})
+	return slices.Clone(amounts), err
+}
+
+// cached returns the cached result of method for key, or loads and caches it.
+// Callers get shared values, so slices and pointers must be copied before
+// they are handed out.
+func cached[T any](ctx context.Context, s *CachedStore, method, key string, load func(context.Context) (T, error)) (T, error) {
+	if s.ttl < 0 {
+		return load(ctx)
+	}
+
+	scope := scopeOf(ctx)
+	key = method + ":" + scope + ":" + key
+	now := time.Now()
+
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if ok && now.Before(e.expires) {
+		cacheMetrics.Add(method+".hits", 1)
+		return e.value.(T), nil
+	}
+
+	if ok {
+		s.mu.Lock()
+		// it may have been reloaded meanwhile
+		if e, ok := s.entries[key]; ok && !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+		s.mu.Unlock()
+	}
+
+	cacheMetrics.Add(method+".misses", 1)
+
+	s.mu.RLock()
+	gen := s.generation(scope)
+	s.mu.RUnlock()
+
+	// a load started before an invalidation must not be joined after it
+	v, err, _ := s.group.Do(gen+":"+key, func() (any, error) {
+		// detached, so one caller going away does not fail the others
+		v, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		now := time.Now()
+		if s.generation(scope) == gen {
+			s.entries[key] = cacheEntry{scope: scope, value: v, expires: now.Add(s.ttl)}
+		}
+		if !now.Before(s.nextSweep) {
+			s.sweep(now)
+		}
+		s.mu.Unlock()
+
+		return v, nil
+	})
+
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("load %s: %w", method, err)
+	}
+
+	return v.(T), nil
+}
+
+// generation identifies the entries of scope until the next Invalidate
+// affecting it; s.mu must be held.
+func (s *CachedStore) generation(scope string) string {
+	return strconv.FormatUint(s.epoch, 10) + "." + strconv.FormatUint(s.generations[scope], 10)
+}
+
+// sweep drops the expired entries; s.mu must be held.
+func (s *CachedStore) sweep(now time.Time) {
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.nextSweep = now.Add(s.ttl)
+}
+
+// unscoped is the scope of loads without a tenant, which span all of them.
+const unscoped = "*"
+
+// scopeOf returns the tenant results for ctx are cached under.
+func scopeOf(ctx context.Context) string {
+	if id, ok := tenant.FromCtx(ctx); ok {
+		return id.String()
+	}
+	return unscoped
+}
+
+func rangeKey(r Range) string {
+	return strconv.FormatInt(r.From.UnixNano(), 10) + "-" + strconv.FormatInt(r.To.UnixNano(), 10)
+}
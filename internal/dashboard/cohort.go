@@ -0,0 +1,121 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cohort groups the customers whose first invoice falls in Month. A customer
+// is active in a month when they are invoiced in it.
+type Cohort struct {
+	Month   time.Time
+	Size    int
+	Periods []CohortPeriod
+}
+
+// CohortPeriod is the cohort's activity Offset months after acquisition;
+// offset 0 is the acquisition month itself.
+type CohortPeriod struct {
+	Offset int
+	Active int
+	// Retention is Active / cohort size, in percent.
+	Retention float64
+	// Revenue is the amount invoiced to the cohort in the month.
+	Revenue float64
+}
+
+// FirstInvoice is the date of a customer's first invoice.
+type FirstInvoice struct {
+	CustomerID uuid.UUID
+	Date       time.Time
+}
+
+// GetCohorts returns the cohorts acquired from the month of from through the
+// month of to, with their activity up to the month of to.
+func (s *Service) GetCohorts(ctx context.Context, from, to time.Time) ([]Cohort, error) {
+	r := Range{From: GranularityMonth.Truncate(from), To: GranularityMonth.Next(GranularityMonth.Truncate(to))}
+
+	firsts, err := s.store.ListFirstInvoices(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("list first invoices: %w", err)
+	}
+
+	// the cohorts' invoices are all on or after their first one
+	amounts, err := s.store.ListInvoiceAmounts(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("list invoice amounts: %w", err)
+	}
+
+	return buildCohorts(firsts, amounts, r.From, r.To), nil
+}
+
+// buildCohorts groups the customers of firsts by the month of their first
+// invoice; amounts of other customers are ignored.
+func buildCohorts(firsts []FirstInvoice, amounts []InvoiceAmount, from, end time.Time) []Cohort {
+	loc := from.Location()
+
+	// months counted from year zero, so offsets are plain differences
+	monthNo := func(t time.Time) int {
+		y, m, _ := t.In(loc).Date()
+		return y*12 + int(m) - 1
+	}
+
+	first := make(map[uuid.UUID]int, len(firsts))
+	for _, f := range firsts {
+		first[f.CustomerID] = monthNo(f.Date)
+	}
+
+	type key struct {
+		cohort, offset int
+	}
+
+	active := make(map[key]map[uuid.UUID]bool)
+	revenue := make(map[key]float64)
+	size := make(map[int]int)
+
+	for _, m := range first {
+		size[m]++
+	}
+
+	for _, a := range amounts {
+		c, ok := first[a.CustomerID]
+		if !ok {
+			// acquired before the report
+			continue
+		}
+		k := key{c, monthNo(a.Date) - c}
+		if active[k] == nil {
+			active[k] = make(map[uuid.UUID]bool)
+		}
+		active[k][a.CustomerID] = true
+		revenue[k] += a.Amount
+	}
+
+	var out []Cohort
+	last := monthNo(end) - 1
+
+	for month := from; month.Before(end); month = month.AddDate(0, 1, 0) {
+		c := monthNo(month)
+		cohort := Cohort{Month: month, Size: size[c]}
+
+		for offset := 0; c+offset <= last; offset++ {
+			k := key{c, offset}
+			p := CohortPeriod{
+				Offset:  offset,
+				Active:  len(active[k]),
+				Revenue: revenue[k],
+			}
+			if cohort.Size > 0 {
+				p.Retention = float64(p.Active) / float64(cohort.Size) * 100
+			}
+			cohort.Periods = append(cohort.Periods, p)
+		}
+
+		out = append(out, cohort)
+	}
+
+	return out
+}
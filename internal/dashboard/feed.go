@@ -0,0 +1,235 @@
+package dashboard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/invoice"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/tenant"
+)
+
+const (
+	// FeedHistory is how many events per tenant are kept for Last-Event-ID replay.
+	FeedHistory = 256
+
+	// feedDebounce coalesces bursts of domain events into one refresh.
+	feedDebounce = 500 * time.Millisecond
+
+	feedBuffer  = 16
+	feedTimeout = 10 * time.Second
+)
+
+type FeedEventType string
+
+const (
+	FeedOverview FeedEventType = "overview"
+	FeedInvoice  FeedEventType = "invoice"
+)
+
+// FeedEvent is a live dashboard update. Overview carries the all-time
+// overview with deltas from the previous one; Invoice a newly created invoice.
+type FeedEvent struct {
+	ID       uint64
+	Type     FeedEventType
+	Overview *OverviewComparison
+	Invoice  *invoice.WithCustomerInfo
+}
+
+// Feed turns domain events into dashboard updates for live subscribers.
+// Each tenant has its own history and subscribers.
+type Feed struct {
+	svc    *Service
+	invSvc *invoice.Service
+	logger logger.Logger
+
+	mu      sync.Mutex
+	nextID  uint64
+	tenants map[uuid.UUID]*feedTenant
+}
+
+type feedTenant struct {
+	// since is the first id history is complete from
+	since    uint64
+	history  []FeedEvent
+	subs     map[chan FeedEvent]struct{}
+	last     *Overview
+	invoices []uuid.UUID
+	timer    *time.Timer
+}
+
+func NewFeed(svc *Service, invSvc *invoice.Service, log logger.Logger) *Feed {
+	return &Feed{
+		svc:    svc,
+		invSvc: invSvc,
+		logger: log.With("component", "dashboard.feed"),
+		// ids from an earlier process are always older than this one's history
+		nextID:  uint64(time.Now().UnixMicro()),
+		tenants: make(map[uuid.UUID]*feedTenant),
+	}
+}
+
+// tenant returns the state of the tenant, creating it. f.mu must be held.
+func (f *Feed) tenant(id uuid.UUID) *feedTenant {
+	t, ok := f.tenants[id]
+	if !ok {
+		t = &feedTenant{since: f.nextID, subs: make(map[chan FeedEvent]struct{})}
+		f.tenants[id] = t
+	}
+	return t
+}
+
+// Notify schedules a refresh for the tenant of ctx. A non-nil invoiceID is
+// pushed as a new invoice. Callers notify once the change is committed.
+func (f *Feed) Notify(ctx context.Context, invoiceID uuid.UUID) {
+	tenantID, ok := tenant.FromCtx(ctx)
+	if !ok {
+		f.logger.WarnContext(ctx, "notify without tenant", "invoice_id", invoiceID)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.tenant(tenantID)
+
+	if invoiceID != uuid.Nil {
+		t.invoices = append(t.invoices, invoiceID)
+	}
+
+	if t.timer == nil {
+		t.timer = time.AfterFunc(feedDebounce, func() { f.refresh(tenantID) })
+	}
+}
+
+// Subscription is a live subscriber. Replay holds the events after the
+// requested id; Complete is false when they are no longer all in history
+// and the client has to start over from a snapshot.
+type Subscription struct {
+	LastID   uint64
+	Replay   []FeedEvent
+	Complete bool
+	Events   <-chan FeedEvent
+}
+
+// Subscribe registers a subscriber to the tenant's updates, replaying events
+// after lastID. The Events channel is closed when the subscriber falls too
+// far behind, or after cancel is called.
+func (f *Feed) Subscribe(tenantID uuid.UUID, lastID uint64) (*Subscription, func()) {
+	ch := make(chan FeedEvent, feedBuffer)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.tenant(tenantID)
+
+	sub := &Subscription{
+		LastID: f.nextID - 1,
+		Events: ch,
+	}
+
+	// ids are shared by all tenants, so gaps in this tenant's history are expected
+	if lastID != 0 && lastID >= t.since-1 && lastID <= sub.LastID {
+		sub.Complete = true
+		for _, e := range t.history {
+			if e.ID > lastID {
+				sub.Replay = append(sub.Replay, e)
+			}
+		}
+	}
+
+	t.subs[ch] = struct{}{}
+
+	cancel := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if _, ok := t.subs[ch]; ok {
+			delete(t.subs, ch)
+			close(ch)
+		}
+	}
+
+	return sub, cancel
+}
+
+// Snapshot returns the current all-time overview of the tenant of ctx.
+func (f *Feed) Snapshot(ctx context.Context) (*Overview, error) {
+	return f.svc.GetOverview(ctx, Range{})
+}
+
+func (f *Feed) refresh(tenantID uuid.UUID) {
+	f.mu.Lock()
+	t := f.tenant(tenantID)
+	ids := t.invoices
+	t.invoices = nil
+	t.timer = nil
+	f.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(tenant.WithID(context.Background(), tenantID), feedTimeout)
+	defer cancel()
+
+	o, err := f.Snapshot(ctx)
+	if err != nil {
+		f.logger.ErrorContext(ctx, "refresh overview", "tenant_id", tenantID, "error", err.Error())
+	} else {
+		f.publishOverview(tenantID, o)
+	}
+
+	for _, id := range ids {
+		inv, err := f.invSvc.GetWithCustomerInfo(ctx, id)
+		if err != nil {
+			// deleted before the refresh ran
+			f.logger.DebugContext(ctx, "refresh invoice", "invoice_id", id, "error", err.Error())
+			continue
+		}
+		f.publish(tenantID, FeedEvent{Type: FeedInvoice, Invoice: inv})
+	}
+}
+
+func (f *Feed) publishOverview(tenantID uuid.UUID, o *Overview) {
+	f.mu.Lock()
+	t := f.tenant(tenantID)
+	prev := t.last
+	t.last = o
+	f.mu.Unlock()
+
+	if prev != nil &&
+		prev.InvoiceCount == o.InvoiceCount &&
+		prev.CustomerCount == o.CustomerCount &&
+		prev.InvoiceStatus == o.InvoiceStatus {
+		return
+	}
+
+	cmp := compareOverviews(*o, prev)
+	f.publish(tenantID, FeedEvent{Type: FeedOverview, Overview: cmp})
+}
+
+func (f *Feed) publish(tenantID uuid.UUID, e FeedEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.tenant(tenantID)
+
+	e.ID = f.nextID
+	f.nextID++
+
+	t.history = append(t.history, e)
+	if len(t.history) > FeedHistory {
+		t.history = t.history[len(t.history)-FeedHistory:]
+		t.since = t.history[0].ID
+	}
+
+	for ch := range t.subs {
+		select {
+		case ch <- e:
+		default:
+			// too slow; the client reconnects with Last-Event-ID
+			delete(t.subs, ch)
+			close(ch)
+		}
+	}
+}
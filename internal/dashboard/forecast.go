@@ -0,0 +1,265 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// The forecast projects cash collected per month, in three steps:
+//
+//  1. Billing. The invoiced amount of each future month is the mean of the
+//     last ForecastLevelMonths full months (the level), times a seasonal
+//     index: that calendar month's share of its year, averaged over the
+//     history. Recurring revenue (MRR) is a floor.
+//  2. Collection. Billing is expected to be paid PaymentLag months later,
+//     the median time-to-pay rounded to months, at the collection rate.
+//  3. Receivables. Open invoices are expected at the collection rate in the
+//     month of invoice date + median time-to-pay; overdue ones in the first
+//     forecast month.
+//
+// Bands come from the one-step errors of the billing model over the
+// history, widening with the square root of the horizon.
+const (
+	ForecastHistoryMonths = 24
+	ForecastLevelMonths   = 3
+	MaxForecastMonths     = 24
+
+	// with fewer backtest errors than this the band is ±25% of the level
+	minForecastResiduals = 3
+	fallbackSpread       = 0.25
+
+	z80 = 1.2816
+	z95 = 1.9600
+)
+
+type ForecastPoint struct {
+	Period time.Time
+	// Billed is the projected amount invoiced in the month.
+	Billed float64
+	// Receivables is the expected collection of currently open invoices.
+	Receivables float64
+	// Expected is the projected cash collected in the month.
+	Expected         float64
+	Lower80, Upper80 float64
+	Lower95, Upper95 float64
+}
+
+type Forecast struct {
+	From   time.Time
+	Points []ForecastPoint
+
+	Level           float64
+	Sigma           float64
+	MRR             float64
+	CollectionRate  float64 // fraction, 1 when nothing was invoiced
+	MedianDaysToPay float64
+	PaymentLag      int // months
+	HistoryMonths   int
+}
+
+// GetForecast projects the months after the one containing now.
+func (s *Service) GetForecast(ctx context.Context, now time.Time, months int) (*Forecast, error) {
+	start := GranularityMonth.Next(GranularityMonth.Truncate(now))
+	histRange := Range{From: start.AddDate(0, -1-ForecastHistoryMonths, 0), To: start.AddDate(0, -1, 0)}
+	kpiRange := Range{From: histRange.To.AddDate(-1, 0, 0), To: histRange.To}
+
+	var (
+		billing []InvoiceAmount
+		open    []InvoiceAmount
+		kpis    *KPIs
+	)
+
+	g, egCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() (err error) {
+		billing, err = s.store.ListInvoiceAmounts(egCtx, histRange)
+		return
+	})
+
+	g.Go(func() (err error) {
+		open, err = s.store.ListOpenInvoices(egCtx)
+		return
+	})
+
+	g.Go(func() (err error) {
+		kpis, err = s.GetKPIs(egCtx, kpiRange)
+		return
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("query forecast inputs: %w", err)
+	}
+
+	f := &Forecast{
+		From:           start,
+		MRR:            kpis.MRR,
+		CollectionRate: 1,
+	}
+	if kpis.CollectionRate != nil {
+		f.CollectionRate = *kpis.CollectionRate / 100
+	}
+	if kpis.MedianDaysToPay != nil {
+		f.MedianDaysToPay = *kpis.MedianDaysToPay
+		f.PaymentLag = int(math.Round(f.MedianDaysToPay / 30.44))
+	}
+
+	history := monthlyTotals(billing, histRange, start.Location())
+	f.HistoryMonths = len(history)
+
+	billed := forecastBilling(f, history, histRange.To, start, months)
+	receivables := expectedReceivables(open, f, start, months)
+
+	for i := range months {
+		p := ForecastPoint{
+			Period:      start.AddDate(0, i, 0),
+			Billed:      billed[i],
+			Receivables: receivables[i],
+			Expected:    receivables[i],
+		}
+
+		// billing of earlier forecast months collected in this one
+		if j := i - f.PaymentLag; j >= 0 {
+			p.Expected += billed[j] * f.CollectionRate
+		}
+
+		spread := 0.0
+		if steps := i - f.PaymentLag + 1; steps > 0 {
+			spread = f.Sigma * f.CollectionRate * math.Sqrt(float64(steps))
+		}
+		p.Lower80, p.Upper80 = max(p.Expected-z80*spread, 0), p.Expected+z80*spread
+		p.Lower95, p.Upper95 = max(p.Expected-z95*spread, 0), p.Expected+z95*spread
+
+		f.Points = append(f.Points, p)
+	}
+
+	return f, nil
+}
+
+// monthlyTotals sums amounts per month of r, dropping the months before the
+// first with any billing so a young business is not averaged with zeros.
+func monthlyTotals(amounts []InvoiceAmount, r Range, loc *time.Location) []float64 {
+	daily := make([]Revenue, len(amounts))
+	for i, a := range amounts {
+		daily[i] = Revenue{Period: a.Date.In(loc), Amount: a.Amount}
+	}
+
+	q := RevenueQuery{From: r.From.In(loc), To: r.To.In(loc).AddDate(0, 0, -1), Granularity: GranularityMonth}
+	buckets, _ := bucketRevenues(q, daily)
+
+	out := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if len(out) == 0 && b.Amount == 0 {
+			continue
+		}
+		out = append(out, b.Amount)
+	}
+
+	return out
+}
+
+// forecastBilling projects billing for months starting at start, and sets
+// the level and sigma of f. history ends with the month before histEnd.
+func forecastBilling(f *Forecast, history []float64, histEnd, start time.Time, months int) []float64 {
+	out := make([]float64, months)
+	if len(history) == 0 {
+		for i := range out {
+			out[i] = f.MRR
+		}
+		return out
+	}
+
+	// calendar month of history[i]
+	monthOf := func(i int) int {
+		return int(histEnd.AddDate(0, i-len(history), 0).Month()) - 1
+	}
+
+	index := seasonalIndex(history, monthOf)
+
+	level := func(end int) float64 {
+		from := max(end-ForecastLevelMonths, 0)
+		var sum float64
+		for _, v := range history[from:end] {
+			sum += v
+		}
+		return sum / float64(end-from)
+	}
+
+	// one-step errors over the history
+	var sq float64
+	var n int
+	for t := ForecastLevelMonths; t < len(history); t++ {
+		e := history[t] - level(t)*index[monthOf(t)]
+		sq += e * e
+		n++
+	}
+
+	f.Level = level(len(history))
+	if n >= minForecastResiduals {
+		f.Sigma = math.Sqrt(sq / float64(n))
+	} else {
+		f.Sigma = f.Level * fallbackSpread
+	}
+
+	for i := range out {
+		m := int(start.AddDate(0, i, 0).Month()) - 1
+		out[i] = max(f.Level*index[m], f.MRR)
+	}
+
+	return out
+}
+
+// seasonalIndex is, per calendar month, the mean ratio of that month to the
+// average of the 12 months ending with it. Months without a full year
+// before them keep an index of 1.
+func seasonalIndex(history []float64, monthOf func(int) int) [12]float64 {
+	var sum [12]float64
+	var n [12]int
+
+	for t := 11; t < len(history); t++ {
+		var year float64
+		for _, v := range history[t-11 : t+1] {
+			year += v
+		}
+		if year == 0 {
+			continue
+		}
+		sum[monthOf(t)] += history[t] / (year / 12)
+		n[monthOf(t)]++
+	}
+
+	var out [12]float64
+	for m := range out {
+		out[m] = 1
+		if n[m] > 0 {
+			out[m] = sum[m] / float64(n[m])
+		}
+	}
+
+	return out
+}
+
+func expectedReceivables(open []InvoiceAmount, f *Forecast, start time.Time, months int) []float64 {
+	out := make([]float64, months)
+	lag := time.Duration(f.MedianDaysToPay * float64(24*time.Hour))
+
+	for _, inv := range open {
+		due := GranularityMonth.Truncate(inv.Date.Add(lag).In(start.Location()))
+
+		i := 0
+		if due.After(start) {
+			y1, m1, _ := start.Date()
+			y2, m2, _ := due.Date()
+			i = (y2-y1)*12 + int(m2-m1)
+		}
+
+		if i < months {
+			out[i] += inv.Amount * f.CollectionRate
+		}
+	}
+
+	return out
+}
@@ -8,31 +8,41 @@ import (
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 
+	"github.com/gelozr/go-dash/internal/config"
 	"github.com/gelozr/go-dash/internal/customer"
 	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/invoice"
 	"github.com/gelozr/go-dash/internal/logger"
 )
 
-type revenueModel struct {
-	Month   string
-	Revenue float64
+type dailyRevenueRow struct {
+	Day    time.Time
+	Amount float64
 }
 
-func (m *revenueModel) TableName() string {
-	return "revenues"
+type paidRevenueRow struct {
+	At     time.Time
+	Amount float64
+}
+
+type paymentDelayRow struct {
+	Date   time.Time
+	PaidAt time.Time
 }
 
 type GormStore struct {
-	db     *gorm.DB
+	db *gorm.DB
+	// loc is the zone datetimes are stored in.
+	loc    *time.Location
 	logger logger.Logger
 }
 
 var _ Store = (*GormStore)(nil)
 
-func NewStore(db *gorm.DB, logger logger.Logger) *GormStore {
+func NewStore(db *gorm.DB, cfg *config.Config, logger logger.Logger) *GormStore {
 	return &GormStore{
 		db:     db,
+		loc:    cfg.Location,
 		logger: logger.With("component", "store.gorm.dash"),
 	}
 }
@@ -44,36 +54,48 @@ func (s *GormStore) DB(ctx context.Context) *gorm.DB {
 	return s.db.WithContext(ctx)
 }
 
-func (s *GormStore) GetOverview(ctx context.Context) (*Overview, error) {
+func (s *GormStore) GetOverview(ctx context.Context, r Range) (*Overview, error) {
 	var (
 		invoiceCount  int64
 		customerCount int64
 		invoiceStatus InvoiceStatus
 	)
 
+	dateCond, dateArgs := rangeCond("date", r)
+	paidCond, paidArgs := rangeCond("COALESCE(paid_at, date)", r)
+
 	g, egCtx := errgroup.WithContext(ctx)
 
 	start := time.Now()
 
 	g.Go(func() error {
-		if err := s.DB(egCtx).Model(&invoice.Invoice{}).Count(&invoiceCount).Error; err != nil {
+		if err := s.DB(egCtx).Model(&invoice.Invoice{}).Where(dateCond, dateArgs...).Count(&invoiceCount).Error; err != nil {
 			return fmt.Errorf("query invoice count: %w", err)
 		}
 		return nil
 	})
 
 	g.Go(func() error {
-		if err := s.DB(egCtx).Model(&customer.Customer{}).Count(&customerCount).Error; err != nil {
+		tx := s.DB(egCtx).Model(&customer.Customer{})
+		if !r.IsZero() {
+			// customers carry no creation date, so a period counts the customers invoiced in it
+			tx = s.DB(egCtx).Model(&invoice.Invoice{}).Distinct("customer_id").Where(dateCond, dateArgs...)
+		}
+
+		if err := tx.Count(&customerCount).Error; err != nil {
 			return fmt.Errorf("query customer count: %w", err)
 		}
 		return nil
 	})
 
 	g.Go(func() error {
+		args := append(append([]any{invoice.StatusPaid}, paidArgs...), invoice.StatusPending)
+		args = append(args, dateArgs...)
+
 		err := s.DB(egCtx).Model(&invoice.Invoice{}).Select(`
-			SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END) AS "paid",
-			SUM(CASE WHEN status = 'pending' THEN amount ELSE 0 END) AS "pending"
-		`).Scan(&invoiceStatus).Error
+			SUM(CASE WHEN status = ? AND `+paidCond+` THEN amount ELSE 0 END) AS "paid",
+			SUM(CASE WHEN status = ? AND `+dateCond+` THEN amount ELSE 0 END) AS "pending"
+		`, args...).Scan(&invoiceStatus).Error
 
 		if err != nil {
 			return fmt.Errorf("query invoice status: %w", err)
@@ -88,26 +110,202 @@ func (s *GormStore) GetOverview(ctx context.Context) (*Overview, error) {
 	s.logger.DebugContext(ctx, "fetch overview", "elapsed", time.Since(start).String())
 
 	return &Overview{
+		Range:         r,
 		InvoiceCount:  invoiceCount,
 		CustomerCount: customerCount,
 		InvoiceStatus: invoiceStatus,
 	}, nil
 }
 
-func (s *GormStore) ListMonthlyRevenues(ctx context.Context) ([]MonthlyRevenue, error) {
-	var models []revenueModel
+// rangeCond builds a condition limiting expr to r; an unbounded side is left out.
+func rangeCond(expr string, r Range) (string, []any) {
+	switch {
+	case r.From.IsZero() && r.To.IsZero():
+		return "1 = 1", nil
+	case r.From.IsZero():
+		return expr + " < ?", []any{r.To}
+	case r.To.IsZero():
+		return expr + " >= ?", []any{r.From}
+	default:
+		return "(" + expr + " >= ? AND " + expr + " < ?)", []any{r.From, r.To}
+	}
+}
+
+func (s *GormStore) ListDailyRevenues(ctx context.Context, from, to time.Time) ([]Revenue, error) {
+	if loc := from.Location(); loc.String() != s.loc.String() {
+		return s.listDailyRevenuesIn(ctx, from, to, loc)
+	}
+
+	var rows []dailyRevenueRow
+
+	// revenue is recognised when paid; invoices paid before paid_at existed fall back to their date
+	err := s.DB(ctx).
+		Table("invoices").
+		Select("DATE(COALESCE(paid_at, date)) AS day, SUM(amount) AS amount").
+		Where("status = ?", invoice.StatusPaid).
+		Where("COALESCE(paid_at, date) >= ? AND COALESCE(paid_at, date) < ?", from, to).
+		Group("day").
+		Order("day").
+		Scan(&rows).Error
 
-	if err := s.DB(ctx).Find(&models).Error; err != nil {
-		return nil, fmt.Errorf("query monthly revenues: %w", err)
+	if err != nil {
+		return nil, fmt.Errorf("query daily revenues: %w", err)
 	}
 
-	out := make([]MonthlyRevenue, len(models))
-	for i, v := range models {
-		out[i] = MonthlyRevenue{
-			Month:  v.Month,
-			Amount: v.Revenue,
+	out := make([]Revenue, len(rows))
+	for i, r := range rows {
+		out[i] = Revenue{
+			Period: r.Day,
+			Amount: r.Amount,
 		}
 	}
 
 	return out, nil
 }
+
+// listDailyRevenuesIn sums paid invoices per calendar day of loc. The days
+// are bucketed here rather than with CONVERT_TZ, which needs the MySQL time
+// zone tables for named zones and yields NULL without them.
+func (s *GormStore) listDailyRevenuesIn(ctx context.Context, from, to time.Time, loc *time.Location) ([]Revenue, error) {
+	var rows []paidRevenueRow
+
+	err := s.DB(ctx).
+		Table("invoices").
+		Select("COALESCE(paid_at, date) AS at, amount").
+		Where("status = ?", invoice.StatusPaid).
+		Where("COALESCE(paid_at, date) >= ? AND COALESCE(paid_at, date) < ?", from, to).
+		Order("at").
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query paid revenues: %w", err)
+	}
+
+	var out []Revenue
+	for _, r := range rows {
+		// the datetime is stored in s.loc whatever zone the driver read it in
+		at := time.Date(r.At.Year(), r.At.Month(), r.At.Day(), r.At.Hour(), r.At.Minute(), r.At.Second(), r.At.Nanosecond(), s.loc)
+
+		y, m, d := at.In(loc).Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+		if n := len(out); n > 0 && out[n-1].Period.Equal(day) {
+			out[n-1].Amount += r.Amount
+			continue
+		}
+		out = append(out, Revenue{Period: day, Amount: r.Amount})
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) GetInvoiceTotals(ctx context.Context, r Range) (InvoiceTotals, error) {
+	var totals InvoiceTotals
+
+	cond, args := rangeCond("date", r)
+
+	err := s.DB(ctx).Model(&invoice.Invoice{}).Select(`
+			COUNT(*) AS count,
+			COALESCE(SUM(amount), 0) AS amount,
+			COALESCE(SUM(CASE WHEN status = ? THEN amount ELSE 0 END), 0) AS paid_amount
+		`, invoice.StatusPaid).
+		Where(cond, args...).
+		Scan(&totals).Error
+
+	if err != nil {
+		return InvoiceTotals{}, fmt.Errorf("query invoice totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+func (s *GormStore) SumReceivables(ctx context.Context, at time.Time) (float64, error) {
+	var sum float64
+
+	err := s.DB(ctx).Model(&invoice.Invoice{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("date < ?", at).
+		Where("status = ? OR paid_at >= ?", invoice.StatusPending, at).
+		Scan(&sum).Error
+
+	if err != nil {
+		return 0, fmt.Errorf("query receivables: %w", err)
+	}
+
+	return sum, nil
+}
+
+func (s *GormStore) ListPaymentDelays(ctx context.Context, r Range) ([]time.Duration, error) {
+	var rows []paymentDelayRow
+
+	cond, args := rangeCond("paid_at", r)
+
+	err := s.DB(ctx).Model(&invoice.Invoice{}).
+		Select("date, paid_at").
+		Where("status = ? AND paid_at IS NOT NULL AND date IS NOT NULL", invoice.StatusPaid).
+		Where(cond, args...).
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query payment delays: %w", err)
+	}
+
+	out := make([]time.Duration, len(rows))
+	for i, r := range rows {
+		out[i] = r.PaidAt.Sub(r.Date)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) ListInvoiceAmounts(ctx context.Context, r Range) ([]InvoiceAmount, error) {
+	var out []InvoiceAmount
+
+	cond, args := rangeCond("date", r)
+
+	err := s.DB(ctx).Model(&invoice.Invoice{}).
+		Select("customer_id, date, amount").
+		Where("customer_id IS NOT NULL").
+		Where(cond, args...).
+		Scan(&out).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query invoice amounts: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) ListFirstInvoices(ctx context.Context, r Range) ([]FirstInvoice, error) {
+	var out []FirstInvoice
+
+	cond, args := rangeCond("MIN(date)", r)
+
+	err := s.DB(ctx).Model(&invoice.Invoice{}).
+		Select("customer_id, MIN(date) AS date").
+		Where("customer_id IS NOT NULL").
+		Group("customer_id").
+		Having(cond, args...).
+		Scan(&out).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query first invoices: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) ListOpenInvoices(ctx context.Context) ([]InvoiceAmount, error) {
+	var out []InvoiceAmount
+
+	err := s.DB(ctx).Model(&invoice.Invoice{}).
+		Select("customer_id, date, amount").
+		Where("status = ? AND date IS NOT NULL", invoice.StatusPending).
+		Scan(&out).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query open invoices: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,153 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+// RecurringMonths is how many consecutive full months a customer must be
+// invoiced in to count towards MRR.
+const RecurringMonths = 3
+
+// InvoiceTotals aggregates the invoices dated within a range.
+type InvoiceTotals struct {
+	Count      int64
+	Amount     float64
+	PaidAmount float64
+}
+
+// InvoiceAmount is a single invoice's customer, date and amount.
+type InvoiceAmount struct {
+	CustomerID uuid.UUID
+	Date       time.Time
+	Amount     float64
+}
+
+// KPIs are financial indicators over a range. Ratios are nil when their
+// denominator is zero.
+type KPIs struct {
+	Range  Range
+	Totals InvoiceTotals
+
+	// Receivables is the amount invoiced before Range.To and not yet paid by then.
+	Receivables float64
+	// DSO (days sales outstanding) is Receivables / invoiced amount * days in range.
+	DSO *float64
+	// CollectionRate is the share of the invoiced amount that is paid, in percent.
+	CollectionRate *float64
+	// AverageInvoiceValue is the invoiced amount / invoice count.
+	AverageInvoiceValue *float64
+	// MedianDaysToPay is the median of paid_at - date, over invoices paid within the range.
+	MedianDaysToPay *float64
+	// MRR is the average monthly amount invoiced over the RecurringMonths full
+	// months before Range.To, to customers invoiced in every one of them.
+	// There are no subscriptions, so recurrence is inferred from billing.
+	MRR float64
+}
+
+// GetKPIs computes the KPIs for a bounded range.
+func (s *Service) GetKPIs(ctx context.Context, r Range) (*KPIs, error) {
+	var (
+		totals      InvoiceTotals
+		receivables float64
+		delays      []time.Duration
+		billing     []InvoiceAmount
+	)
+
+	mrrEnd := GranularityMonth.Truncate(r.To)
+	mrrRange := Range{From: mrrEnd.AddDate(0, -RecurringMonths, 0), To: mrrEnd}
+
+	g, egCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() (err error) {
+		totals, err = s.store.GetInvoiceTotals(egCtx, r)
+		return
+	})
+
+	g.Go(func() (err error) {
+		receivables, err = s.store.SumReceivables(egCtx, r.To)
+		return
+	})
+
+	g.Go(func() (err error) {
+		delays, err = s.store.ListPaymentDelays(egCtx, r)
+		return
+	})
+
+	g.Go(func() (err error) {
+		billing, err = s.store.ListInvoiceAmounts(egCtx, mrrRange)
+		return
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("query kpis: %w", err)
+	}
+
+	k := &KPIs{
+		Range:           r,
+		Totals:          totals,
+		Receivables:     receivables,
+		MedianDaysToPay: medianDays(delays),
+		MRR:             recurringRevenue(billing, mrrRange),
+	}
+
+	if totals.Amount > 0 {
+		days := r.To.Sub(r.From).Hours() / 24
+		dso := receivables / totals.Amount * days
+		rate := totals.PaidAmount / totals.Amount * 100
+		k.DSO = &dso
+		k.CollectionRate = &rate
+	}
+
+	if totals.Count > 0 {
+		avg := totals.Amount / float64(totals.Count)
+		k.AverageInvoiceValue = &avg
+	}
+
+	return k, nil
+}
+
+func medianDays(delays []time.Duration) *float64 {
+	if len(delays) == 0 {
+		return nil
+	}
+
+	slices.Sort(delays)
+
+	mid := len(delays) / 2
+	m := delays[mid]
+	if len(delays)%2 == 0 {
+		m = (delays[mid-1] + delays[mid]) / 2
+	}
+
+	days := max(m.Hours()/24, 0)
+	return &days
+}
+
+func recurringRevenue(billing []InvoiceAmount, r Range) float64 {
+	months := make(map[uuid.UUID]map[int64]bool)
+	amounts := make(map[uuid.UUID]float64)
+
+	for _, b := range billing {
+		month := GranularityMonth.Truncate(b.Date.In(r.From.Location())).Unix()
+		if months[b.CustomerID] == nil {
+			months[b.CustomerID] = make(map[int64]bool)
+		}
+		months[b.CustomerID][month] = true
+		amounts[b.CustomerID] += b.Amount
+	}
+
+	var total float64
+	for id, m := range months {
+		if len(m) == RecurringMonths {
+			total += amounts[id]
+		}
+	}
+
+	return total / RecurringMonths
+}
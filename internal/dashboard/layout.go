@@ -0,0 +1,246 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customfield"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+var (
+	ErrLayoutNotFound = errors.New("dashboard layout not found")
+	ErrInvalidLayout  = errors.New("invalid dashboard layout")
+)
+
+// LayoutError lists the reasons each widget option is invalid, keyed
+// "widgets.<index>.<option>". It wraps ErrInvalidLayout.
+type LayoutError struct {
+	Fields map[string][]string
+}
+
+func (e *LayoutError) Error() string {
+	return ErrInvalidLayout.Error()
+}
+
+func (e *LayoutError) Unwrap() error {
+	return ErrInvalidLayout
+}
+
+const MaxWidgets = 20
+
+var widgetIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+type WidgetType string
+
+const (
+	WidgetOverview       WidgetType = "overview"
+	WidgetRevenues       WidgetType = "revenues"
+	WidgetKPIs           WidgetType = "kpis"
+	WidgetTopCustomers   WidgetType = "top_customers"
+	WidgetForecast       WidgetType = "forecast"
+	WidgetCohorts        WidgetType = "cohorts"
+	WidgetLatestInvoices WidgetType = "latest_invoices"
+)
+
+// Widget is one dashboard tile. Which options apply depends on Type; the
+// rest must be left empty.
+type Widget struct {
+	ID          string
+	Type        WidgetType
+	Period      Period
+	Granularity Granularity
+	Metric      Metric
+	Limit       int
+	// Months is the span of revenues and cohorts, or the forecast horizon.
+	Months  int
+	Filters customfield.Filters
+}
+
+// Layout is a user's dashboard; widgets are shown in slice order.
+type Layout struct {
+	UserID    uuid.UUID
+	Widgets   []Widget
+	UpdatedAt time.Time
+}
+
+// DefaultLayout is shown to users who have not saved their own.
+func DefaultLayout(userID uuid.UUID) Layout {
+	return Layout{
+		UserID: userID,
+		Widgets: []Widget{
+			{ID: "overview", Type: WidgetOverview, Period: PeriodMonth},
+			{ID: "revenues", Type: WidgetRevenues, Granularity: GranularityMonth, Months: 12},
+			{ID: "latest-invoices", Type: WidgetLatestInvoices, Limit: 5},
+		},
+	}
+}
+
+type LayoutStore interface {
+	FindLayout(ctx context.Context, userID uuid.UUID) (*Layout, error)
+	SaveLayout(ctx context.Context, l Layout) error
+}
+
+type LayoutService struct {
+	store  LayoutStore
+	logger logger.Logger
+}
+
+func NewLayoutService(store LayoutStore, log logger.Logger) *LayoutService {
+	return &LayoutService{
+		store:  store,
+		logger: log.With("component", "service.dashboard.layout"),
+	}
+}
+
+// Get returns the user's layout, or the default when none is saved.
+func (s *LayoutService) Get(ctx context.Context, userID uuid.UUID) (*Layout, error) {
+	l, err := s.store.FindLayout(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrLayoutNotFound) {
+			d := DefaultLayout(userID)
+			return &d, nil
+		}
+		return nil, fmt.Errorf("find layout: %w", err)
+	}
+
+	return l, nil
+}
+
+// Save validates and stores the user's layout. Invalid widgets are reported
+// as a LayoutError.
+func (s *LayoutService) Save(ctx context.Context, userID uuid.UUID, widgets []Widget) (*Layout, error) {
+	if err := validateWidgets(widgets); err != nil {
+		return nil, err
+	}
+
+	l := Layout{
+		UserID:    userID,
+		Widgets:   widgets,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.store.SaveLayout(ctx, l); err != nil {
+		return nil, fmt.Errorf("save layout: %w", err)
+	}
+
+	return &l, nil
+}
+
+func validateWidgets(widgets []Widget) error {
+	errs := make(map[string][]string)
+
+	if len(widgets) > MaxWidgets {
+		errs["widgets"] = append(errs["widgets"], fmt.Sprintf("widgets must contain at most %d items", MaxWidgets))
+		return &LayoutError{Fields: errs}
+	}
+
+	seen := make(map[string]bool, len(widgets))
+
+	for i, w := range widgets {
+		field := func(name string) string {
+			return fmt.Sprintf("widgets.%d.%s", i, name)
+		}
+		fail := func(name, msg string) {
+			errs[field(name)] = append(errs[field(name)], msg)
+		}
+
+		switch {
+		case !widgetIDPattern.MatchString(w.ID):
+			fail("id", "id must be 1-32 lowercase letters, digits, dashes or underscores")
+		case seen[w.ID]:
+			fail("id", "id must be unique")
+		}
+		seen[w.ID] = true
+
+		// options each type accepts
+		var period, granularity, metric, limit, months bool
+
+		switch w.Type {
+		case WidgetOverview:
+			period = true
+		case WidgetRevenues:
+			granularity, months = true, true
+		case WidgetKPIs:
+			period = true
+		case WidgetTopCustomers:
+			period, metric, limit = true, true, true
+		case WidgetForecast:
+			months = true
+		case WidgetCohorts:
+			months = true
+		case WidgetLatestInvoices:
+			limit = true
+		default:
+			fail("type", "type must be one of overview, revenues, kpis, top_customers, forecast, cohorts, latest_invoices")
+			continue
+		}
+
+		switch {
+		case w.Period == "":
+		case !period:
+			fail("period", "period is not supported by this widget")
+		case w.Type == WidgetKPIs && w.Period == PeriodAll:
+			fail("period", "period must be one of month, quarter, ytd")
+		case !w.Period.Valid():
+			fail("period", "period must be one of all, month, quarter, ytd")
+		}
+
+		switch {
+		case w.Granularity == "":
+		case !granularity:
+			fail("granularity", "granularity is not supported by this widget")
+		case !w.Granularity.Valid():
+			fail("granularity", "granularity must be one of day, week, month, quarter")
+		}
+
+		switch {
+		case w.Metric == "":
+		case !metric:
+			fail("metric", "metric is not supported by this widget")
+		case !w.Metric.Valid():
+			fail("metric", "metric must be one of paid, pending, count")
+		}
+
+		switch {
+		case w.Limit == 0:
+		case !limit:
+			fail("limit", "limit is not supported by this widget")
+		case w.Limit < 1 || w.Limit > 100:
+			fail("limit", "limit must be between 1 and 100")
+		}
+
+		maxMonths := 120
+		if w.Type == WidgetForecast {
+			maxMonths = MaxForecastMonths
+		}
+
+		switch {
+		case w.Months == 0:
+		case !months:
+			fail("months", "months is not supported by this widget")
+		case w.Months < 1 || w.Months > maxMonths:
+			fail("months", fmt.Sprintf("months must be between 1 and %d", maxMonths))
+		}
+
+		for k, v := range w.Filters {
+			if !customfield.KeyPattern.MatchString(k) {
+				fail("filters."+k, "filter must name a custom field key")
+			}
+			if len(v) > 255 {
+				fail("filters."+k, "filter must not be longer than 255 characters")
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &LayoutError{Fields: errs}
+	}
+
+	return nil
+}
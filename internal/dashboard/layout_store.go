@@ -0,0 +1,136 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gelozr/go-dash/internal/customfield"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type layoutModel struct {
+	UserID    uuid.UUID `gorm:"type:char(36);not nullable;primary_key"`
+	Widgets   string    `gorm:"type:json;not nullable"`
+	UpdatedAt time.Time `gorm:"not nullable"`
+}
+
+func (*layoutModel) TableName() string {
+	return "dashboard_layouts"
+}
+
+// widgetJSON is the stored form of a widget.
+type widgetJSON struct {
+	ID          string              `json:"id"`
+	Type        string              `json:"type"`
+	Period      string              `json:"period,omitempty"`
+	Granularity string              `json:"granularity,omitempty"`
+	Metric      string              `json:"metric,omitempty"`
+	Limit       int                 `json:"limit,omitempty"`
+	Months      int                 `json:"months,omitempty"`
+	Filters     customfield.Filters `json:"filters,omitempty"`
+}
+
+type GormLayoutStore struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+var _ LayoutStore = (*GormLayoutStore)(nil)
+
+func NewLayoutStore(db *gorm.DB, log logger.Logger) *GormLayoutStore {
+	return &GormLayoutStore{
+		db:     db,
+		logger: log.With("component", "store.gorm.dash.layout"),
+	}
+}
+
+func (s *GormLayoutStore) DB(ctx context.Context) *gorm.DB {
+	if gormDB, ok := db.FromCtx(ctx); ok {
+		return gormDB.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+func (s *GormLayoutStore) FindLayout(ctx context.Context, userID uuid.UUID) (*Layout, error) {
+	var model layoutModel
+
+	if err := s.DB(ctx).First(&model, "user_id = ?", userID).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrLayoutNotFound
+		default:
+			return nil, fmt.Errorf("query layout: %w", err)
+		}
+	}
+
+	var stored []widgetJSON
+	if err := json.Unmarshal([]byte(model.Widgets), &stored); err != nil {
+		return nil, fmt.Errorf("unmarshal widgets: %w", err)
+	}
+
+	widgets := make([]Widget, len(stored))
+	for i, w := range stored {
+		widgets[i] = Widget{
+			ID:          w.ID,
+			Type:        WidgetType(w.Type),
+			Period:      Period(w.Period),
+			Granularity: Granularity(w.Granularity),
+			Metric:      Metric(w.Metric),
+			Limit:       w.Limit,
+			Months:      w.Months,
+			Filters:     w.Filters,
+		}
+	}
+
+	return &Layout{
+		UserID:    model.UserID,
+		Widgets:   widgets,
+		UpdatedAt: model.UpdatedAt,
+	}, nil
+}
+
+func (s *GormLayoutStore) SaveLayout(ctx context.Context, l Layout) error {
+	stored := make([]widgetJSON, len(l.Widgets))
+	for i, w := range l.Widgets {
+		stored[i] = widgetJSON{
+			ID:          w.ID,
+			Type:        string(w.Type),
+			Period:      string(w.Period),
+			Granularity: string(w.Granularity),
+			Metric:      string(w.Metric),
+			Limit:       w.Limit,
+			Months:      w.Months,
+			Filters:     w.Filters,
+		}
+	}
+
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("marshal widgets: %w", err)
+	}
+
+	model := layoutModel{
+		UserID:    l.UserID,
+		Widgets:   string(b),
+		UpdatedAt: l.UpdatedAt,
+	}
+
+	err = s.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"widgets", "updated_at"}),
+	}).Create(&model).Error
+
+	if err != nil {
+		return fmt.Errorf("upsert layout: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"time"
+)
+
+// Range is the half-open interval [From, To). A zero bound is unbounded.
+type Range struct {
+	From, To time.Time
+}
+
+func (r Range) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// Previous returns the range of equal length immediately before r.
+func (r Range) Previous() Range {
+	return Range{From: r.From.Add(-r.To.Sub(r.From)), To: r.From}
+}
+
+type Period string
+
+const (
+	PeriodAll     Period = "all"
+	PeriodMonth   Period = "month"
+	PeriodQuarter Period = "quarter"
+	PeriodYTD     Period = "ytd"
+
+	// PeriodCustom labels an explicit date range; it has no Ranges of its own.
+	PeriodCustom Period = "custom"
+)
+
+func (p Period) Valid() bool {
+	switch p {
+	case PeriodAll, PeriodMonth, PeriodQuarter, PeriodYTD:
+		return true
+	}
+	return false
+}
+
+// Ranges returns the to-date range of p at now and the same span of the
+// previous month, quarter or year, e.g. 1-19 Oct against 1-19 Sep. The
+// comparison is clamped to the previous period when it is shorter. PeriodAll
+// has no comparison.
+func (p Period) Ranges(now time.Time) (cur, prev Range) {
+	var start, prevStart, prevEnd time.Time
+
+	switch p {
+	case PeriodMonth:
+		start = GranularityMonth.Truncate(now)
+		prevStart = start.AddDate(0, -1, 0)
+		prevEnd = now.AddDate(0, -1, 0)
+	case PeriodQuarter:
+		start = GranularityQuarter.Truncate(now)
+		prevStart = start.AddDate(0, -3, 0)
+		prevEnd = now.AddDate(0, -3, 0)
+	case PeriodYTD:
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		prevStart = start.AddDate(-1, 0, 0)
+		prevEnd = now.AddDate(-1, 0, 0)
+	default:
+		return Range{}, Range{}
+	}
+
+	// AddDate normalises 31 Mar - 1 month to 3 Mar; stop at the period boundary instead
+	if prevEnd.After(start) {
+		prevEnd = start
+	}
+
+	return Range{From: start, To: now}, Range{From: prevStart, To: prevEnd}
+}
+
+// Delta is the change from a previous value. Percent is nil when the
+// previous value is zero.
+type Delta struct {
+	Absolute float64
+	Percent  *float64
+}
+
+func NewDelta(cur, prev float64) Delta {
+	d := Delta{Absolute: cur - prev}
+	if prev != 0 {
+		pct := d.Absolute / prev * 100
+		d.Percent = &pct
+	}
+	return d
+}
+
+type OverviewDeltas struct {
+	InvoiceCount  Delta
+	CustomerCount Delta
+	Paid          Delta
+	Pending       Delta
+}
+
+// OverviewComparison holds the overview of a period and its comparison period.
+type OverviewComparison struct {
+	Current  Overview
+	Previous *Overview
+	Deltas   *OverviewDeltas
+}
+
+// compareOverviews pairs cur with prev and the deltas between them; a nil
+// prev leaves both out.
+func compareOverviews(cur Overview, prev *Overview) *OverviewComparison {
+	c := &OverviewComparison{Current: cur}
+	if prev == nil {
+		return c
+	}
+
+	c.Previous = prev
+	c.Deltas = &OverviewDeltas{
+		InvoiceCount:  NewDelta(float64(cur.InvoiceCount), float64(prev.InvoiceCount)),
+		CustomerCount: NewDelta(float64(cur.CustomerCount), float64(prev.CustomerCount)),
+		Paid:          NewDelta(cur.InvoiceStatus.Paid, prev.InvoiceStatus.Paid),
+		Pending:       NewDelta(cur.InvoiceStatus.Pending, prev.InvoiceStatus.Pending),
+	}
+
+	return c
+}
@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrTooManyBuckets = errors.New("revenue range has too many buckets")
+
+// MaxRevenueBuckets caps the length of a zero-filled revenue series.
+const MaxRevenueBuckets = 1000
+
+type Granularity string
+
+const (
+	GranularityDay     Granularity = "day"
+	GranularityWeek    Granularity = "week" // ISO weeks, starting on Monday
+	GranularityMonth   Granularity = "month"
+	GranularityQuarter Granularity = "quarter"
+)
+
+func (g Granularity) Valid() bool {
+	switch g {
+	case GranularityDay, GranularityWeek, GranularityMonth, GranularityQuarter:
+		return true
+	}
+	return false
+}
+
+// Revenue is the amount paid within the bucket starting at Period.
+type Revenue struct {
+	Period time.Time
+	Amount float64
+}
+
+// RevenueQuery selects revenue from the day of From through the day of To, inclusive.
+type RevenueQuery struct {
+	From, To    time.Time
+	Granularity Granularity
+}
+
+// Truncate returns the start of the bucket containing t, in t's location.
+func (g Granularity) Truncate(t time.Time) time.Time {
+	y, m, d := t.Date()
+
+	switch g {
+	case GranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case GranularityMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	case GranularityQuarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Next returns the start of the bucket after the one starting at t.
+func (g Granularity) Next(t time.Time) time.Time {
+	switch g {
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return t.AddDate(0, 1, 0)
+	case GranularityQuarter:
+		return t.AddDate(0, 3, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Label formats a bucket start for display, e.g. 2025-03-14, 2025-W11, 2025-03 or 2025-Q1.
+func (g Granularity) Label(t time.Time) string {
+	switch g {
+	case GranularityWeek:
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case GranularityMonth:
+		return t.Format("2006-01")
+	case GranularityQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
+// bucketRevenues groups daily revenues into the query's granularity and
+// fills every bucket between From and To, so the series has no gaps.
+func bucketRevenues(q RevenueQuery, daily []Revenue) ([]Revenue, error) {
+	first := q.Granularity.Truncate(q.From)
+	last := q.Granularity.Truncate(q.To)
+
+	var out []Revenue
+	index := make(map[int64]int)
+
+	for t := first; !t.After(last); t = q.Granularity.Next(t) {
+		if len(out) == MaxRevenueBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		index[t.Unix()] = len(out)
+		out = append(out, Revenue{Period: t})
+	}
+
+	for _, r := range daily {
+		day := r.Period
+		if day.Location() != q.From.Location() {
+			// dates read from the database carry no zone; keep the calendar day
+			y, m, d := day.Date()
+			day = time.Date(y, m, d, 0, 0, 0, 0, q.From.Location())
+		}
+
+		if i, ok := index[q.Granularity.Truncate(day).Unix()]; ok {
+			out[i].Amount += r.Amount
+		}
+	}
+
+	return out, nil
+}
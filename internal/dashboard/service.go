@@ -3,40 +3,77 @@ package dashboard
 import (
 	"context"
 	"fmt"
-	"sort"
 
+	"golang.org/x/sync/errgroup"
+
+	"github.com/gelozr/go-dash/internal/customer"
 	"github.com/gelozr/go-dash/internal/logger"
 )
 
 type Service struct {
-	store  Store
-	logger logger.Logger
+	store   Store
+	custSvc *customer.Service
+	logger  logger.Logger
 }
 
-func NewService(store Store, log logger.Logger) *Service {
+func NewService(store Store, custSvc *customer.Service, log logger.Logger) *Service {
 	return &Service{
-		store:  store,
-		logger: log.With("component", "service.dashboard"),
+		store:   store,
+		custSvc: custSvc,
+		logger:  log.With("component", "service.dashboard"),
 	}
 }
 
-func (s *Service) GetOverview(ctx context.Context) (*Overview, error) {
-	o, err := s.store.GetOverview(ctx)
+func (s *Service) GetOverview(ctx context.Context, r Range) (*Overview, error) {
+	o, err := s.store.GetOverview(ctx, r)
 	if err != nil {
 		return nil, fmt.Errorf("retrieve overview: %w", err)
 	}
 	return o, nil
 }
 
-func (s *Service) GetMonthlyRevenues(ctx context.Context) ([]MonthlyRevenue, error) {
-	revs, err := s.store.ListMonthlyRevenues(ctx)
+// CompareOverview computes the overview of cur and prev concurrently, with
+// the deltas from prev to cur.
+func (s *Service) CompareOverview(ctx context.Context, cur, prev Range) (*OverviewComparison, error) {
+	var c, p *Overview
+
+	g, egCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() (err error) {
+		c, err = s.GetOverview(egCtx, cur)
+		return
+	})
+
+	g.Go(func() (err error) {
+		p, err = s.GetOverview(egCtx, prev)
+		return
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return compareOverviews(*c, p), nil
+}
+
+// GetRevenues returns paid revenue per bucket, zero-filled across the whole range.
+func (s *Service) GetRevenues(ctx context.Context, q RevenueQuery) ([]Revenue, error) {
+	if !q.Granularity.Valid() {
+		q.Granularity = GranularityMonth
+	}
+
+	from := GranularityDay.Truncate(q.From)
+	to := GranularityDay.Next(GranularityDay.Truncate(q.To))
+
+	daily, err := s.store.ListDailyRevenues(ctx, from, to)
 	if err != nil {
-		return nil, fmt.Errorf("list monthly revenues: %w", err)
+		return nil, fmt.Errorf("list daily revenues: %w", err)
 	}
 
-	sort.Slice(revs, func(i, j int) bool {
-		return monthOrder[revs[i].Month] < monthOrder[revs[j].Month]
-	})
+	revs, err := bucketRevenues(q, daily)
+	if err != nil {
+		return nil, err
+	}
 
 	return revs, nil
 }
@@ -1,13 +1,31 @@
 package dashboard
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Store interface {
-	GetOverview(ctx context.Context) (*Overview, error)
-	ListMonthlyRevenues(ctx context.Context) ([]MonthlyRevenue, error)
+	GetOverview(ctx context.Context, r Range) (*Overview, error)
+	// ListDailyRevenues sums paid invoices per calendar day in [from, to),
+	// with days taken in from's location.
+	ListDailyRevenues(ctx context.Context, from, to time.Time) ([]Revenue, error)
+
+	GetInvoiceTotals(ctx context.Context, r Range) (InvoiceTotals, error)
+	// SumReceivables sums invoices dated before at that were unpaid at that time.
+	SumReceivables(ctx context.Context, at time.Time) (float64, error)
+	// ListPaymentDelays returns paid_at - date of the invoices paid within r.
+	ListPaymentDelays(ctx context.Context, r Range) ([]time.Duration, error)
+	ListInvoiceAmounts(ctx context.Context, r Range) ([]InvoiceAmount, error)
+	// ListFirstInvoices returns the customers whose first invoice is dated
+	// within r, with its date.
+	ListFirstInvoices(ctx context.Context, r Range) ([]FirstInvoice, error)
+	// ListOpenInvoices returns the pending invoices.
+	ListOpenInvoices(ctx context.Context) ([]InvoiceAmount, error)
 }
 
 type Overview struct {
+	Range         Range
 	InvoiceCount  int64
 	CustomerCount int64
 	InvoiceStatus InvoiceStatus
@@ -16,23 +34,3 @@ type Overview struct {
 type InvoiceStatus struct {
 	Paid, Pending float64
 }
-
-type MonthlyRevenue struct {
-	Month  string
-	Amount float64
-}
-
-var monthOrder = map[string]int{
-	"Jan": 1,
-	"Feb": 2,
-	"Mar": 3,
-	"Apr": 4,
-	"May": 5,
-	"Jun": 6,
-	"Jul": 7,
-	"Aug": 8,
-	"Sep": 9,
-	"Oct": 10,
-	"Nov": 11,
-	"Dec": 12,
-}
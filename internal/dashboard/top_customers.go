@@ -0,0 +1,116 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/gelozr/go-dash/internal/customer"
+)
+
+type Metric string
+
+const (
+	MetricPaid    Metric = "paid"
+	MetricPending Metric = "pending"
+	MetricCount   Metric = "count"
+)
+
+func (m Metric) Valid() bool {
+	return m == MetricPaid || m == MetricPending || m == MetricCount
+}
+
+func (m Metric) order() customer.InfoOrder {
+	switch m {
+	case MetricPending:
+		return customer.OrderByTotalPending
+	case MetricCount:
+		return customer.OrderByInvoiceCount
+	default:
+		return customer.OrderByTotalPaid
+	}
+}
+
+type TopCustomersQuery struct {
+	Metric Metric
+	Limit  int
+	Range  Range
+}
+
+// Concentration is the share of paid revenue that comes from the top N
+// customers by paid amount.
+type Concentration struct {
+	TopN      int
+	TopPaid   float64
+	TotalPaid float64
+	// Share is TopPaid / TotalPaid in percent, nil when nothing was paid.
+	Share *float64
+}
+
+type TopCustomers struct {
+	Customers     []customer.WithInvoiceInfo
+	Concentration Concentration
+}
+
+// GetTopCustomers ranks customers by the query metric over the invoices dated
+// within the range, along with the revenue concentration of the top Limit.
+func (s *Service) GetTopCustomers(ctx context.Context, q TopCustomersQuery) (*TopCustomers, error) {
+	var (
+		top, topPaid []customer.WithInvoiceInfo
+		totals       InvoiceTotals
+	)
+
+	filter := func(m Metric) customer.SearchFilter {
+		return customer.SearchFilter{
+			InvoicesFrom: q.Range.From,
+			InvoicesTo:   q.Range.To,
+			OrderBy:      m.order(),
+			Limit:        q.Limit,
+		}
+	}
+
+	g, egCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() (err error) {
+		top, err = s.custSvc.SearchWithInvoiceInfo(egCtx, filter(q.Metric))
+		return
+	})
+
+	if q.Metric != MetricPaid {
+		g.Go(func() (err error) {
+			topPaid, err = s.custSvc.SearchWithInvoiceInfo(egCtx, filter(MetricPaid))
+			return
+		})
+	}
+
+	g.Go(func() (err error) {
+		totals, err = s.store.GetInvoiceTotals(egCtx, q.Range)
+		return
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("query top customers: %w", err)
+	}
+
+	if q.Metric == MetricPaid {
+		topPaid = top
+	}
+
+	c := Concentration{
+		TopN:      q.Limit,
+		TotalPaid: totals.PaidAmount,
+	}
+	for _, cust := range topPaid {
+		c.TopPaid += cust.TotalPaid
+	}
+	if c.TotalPaid > 0 {
+		share := c.TopPaid / c.TotalPaid * 100
+		c.Share = &share
+	}
+
+	return &TopCustomers{
+		Customers:     top,
+		Concentration: c,
+	}, nil
+}
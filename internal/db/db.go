@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -19,7 +20,10 @@ import (
 
 type ctxKey string
 
-var dbTxKey = ctxKey("db_tx_key")
+var (
+	dbTxKey          = ctxKey("db_tx_key")
+	dbAfterCommitKey = ctxKey("db_after_commit_key")
+)
 
 func Open(cfg *config.Config, log logger.Logger) (*gorm.DB, error) {
 	wd, _ := os.Getwd()
@@ -27,12 +31,14 @@ func Open(cfg *config.Config, log logger.Logger) (*gorm.DB, error) {
 	logLevel := gormlogger.Warn
 	l := &dbLogger{Logger: log, level: logLevel, basePath: wd}
 
-	dns := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	// datetimes are stored in the app timezone
+	dns := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=%s",
 		cfg.DBUser,
 		url.QueryEscape(cfg.DBPass),
 		cfg.DBHost,
 		cfg.DBPort,
 		cfg.DBName,
+		url.QueryEscape(cfg.Location.String()),
 	)
 
 	db, err := gorm.Open(mysql.Open(dns), &gorm.Config{
@@ -71,12 +77,86 @@ func NewTxManager(db *gorm.DB) *GormTxManager {
 	return &GormTxManager{db: db}
 }
 
+// Do runs fn in a transaction. Inside another Do it runs in a savepoint of
+// the outer transaction, so use cases can compose transactional services.
+// Functions passed to AfterCommit run once the outermost transaction commits.
 func (g *GormTxManager) Do(ctx context.Context, fn func(context.Context) error) error {
-	return g.db.WithContext(ctx).
+	gormDB := g.db
+	tx, nested := FromCtx(ctx)
+	if nested {
+		gormDB = tx
+	}
+
+	hooks, ok := ctx.Value(dbAfterCommitKey).(*afterCommit)
+	if !nested || !ok {
+		hooks = &afterCommit{}
+		ctx = context.WithValue(ctx, dbAfterCommitKey, hooks)
+	}
+	mark := hooks.len()
+
+	err := gormDB.WithContext(ctx).
 		Transaction(func(tx *gorm.DB) error {
-			ctx = context.WithValue(ctx, dbTxKey, tx)
-			return fn(ctx)
+			return fn(context.WithValue(ctx, dbTxKey, tx))
 		})
+
+	switch {
+	case err != nil:
+		// the savepoint rolled back, and what it registered with it
+		hooks.truncate(mark)
+	case !nested:
+		hooks.run()
+	}
+
+	return err
+}
+
+// AfterCommit runs fn once the transaction of ctx commits, or right away
+// outside a transaction. It is dropped if the transaction, or the savepoint
+// it was registered in, rolls back. fn gets ctx without the transaction.
+func AfterCommit(ctx context.Context, fn func(context.Context)) {
+	committed := context.WithValue(context.WithValue(ctx, dbTxKey, nil), dbAfterCommitKey, nil)
+
+	hooks, ok := ctx.Value(dbAfterCommitKey).(*afterCommit)
+	if _, inTx := FromCtx(ctx); !inTx || !ok {
+		fn(committed)
+		return
+	}
+
+	hooks.add(func() { fn(committed) })
+}
+
+type afterCommit struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+func (h *afterCommit) add(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fn)
+}
+
+func (h *afterCommit) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.fns)
+}
+
+func (h *afterCommit) truncate(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = h.fns[:n]
+}
+
+func (h *afterCommit) run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
 }
 
 type dbLogger struct {
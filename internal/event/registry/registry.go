@@ -3,18 +3,35 @@ package registry
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/auth"
+	"github.com/gelozr/go-dash/internal/config"
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/dashboard"
+	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/event"
+	"github.com/gelozr/go-dash/internal/invoice"
 	"github.com/gelozr/go-dash/internal/logger"
 	"github.com/gelozr/go-dash/internal/mail"
+	"github.com/gelozr/go-dash/internal/user"
 )
 
 type RegisterInitializer struct{}
 
 func RegisterAll(
+	cfg *config.Config,
 	broker *event.Broker,
 	custSvc *customer.Service,
+	verifier *customer.Verifier,
+	dashCache *dashboard.CachedStore,
+	dashFeed *dashboard.Feed,
+	userSvc *user.Service,
+	inviter *user.Inviter,
+	resetter *user.Resetter,
 	mailer mail.Mailer,
 	logger logger.Logger,
 ) RegisterInitializer {
@@ -25,11 +42,96 @@ func RegisterAll(
 		_ = custCreatedBus.SetAsyncHandler(asyncHandler[customer.Created](log))
 
 		custCreatedBus.SubscribeAsync(SendWelcomeEmail(custSvc, mailer))
-		custCreatedBus.SubscribeAsync(SendVerifyEmail(custSvc))
+		custCreatedBus.SubscribeAsync(SendVerifyEmail(cfg, custSvc, verifier, mailer))
+		custCreatedBus.Subscribe(InvalidateDashboard[customer.Created](dashCache))
+		custCreatedBus.Subscribe(RefreshDashboardFeed[customer.Created](dashFeed))
 
 		broker.RegisterBus(custCreatedBus)
 	}
 
+	custVerificationRequestedBus := event.NewBus[customer.VerificationRequested]()
+	{
+		_ = custVerificationRequestedBus.SetAsyncHandler(asyncHandler[customer.VerificationRequested](log))
+
+		custVerificationRequestedBus.SubscribeAsync(ResendVerifyEmail(cfg, custSvc, verifier, mailer))
+
+		broker.RegisterBus(custVerificationRequestedBus)
+	}
+
+	custVerifiedBus := event.NewBus[customer.Verified]()
+	{
+		_ = custVerifiedBus.SetAsyncHandler(asyncHandler[customer.Verified](log))
+
+		broker.RegisterBus(custVerifiedBus)
+	}
+
+	custErasedBus := event.NewBus[customer.Erased]()
+	{
+		_ = custErasedBus.SetAsyncHandler(asyncHandler[customer.Erased](log))
+
+		custErasedBus.Subscribe(InvalidateDashboard[customer.Erased](dashCache))
+		custErasedBus.Subscribe(RefreshDashboardFeed[customer.Erased](dashFeed))
+
+		broker.RegisterBus(custErasedBus)
+	}
+
+	invCreatedBus := event.NewBus[invoice.Created]()
+	{
+		_ = invCreatedBus.SetAsyncHandler(asyncHandler[invoice.Created](log))
+
+		invCreatedBus.Subscribe(InvalidateDashboard[invoice.Created](dashCache))
+		invCreatedBus.Subscribe(PushNewInvoice(dashFeed))
+
+		broker.RegisterBus(invCreatedBus)
+	}
+
+	invUpdatedBus := event.NewBus[invoice.Updated]()
+	{
+		_ = invUpdatedBus.SetAsyncHandler(asyncHandler[invoice.Updated](log))
+
+		invUpdatedBus.Subscribe(InvalidateDashboard[invoice.Updated](dashCache))
+		invUpdatedBus.Subscribe(RefreshDashboardFeed[invoice.Updated](dashFeed))
+
+		broker.RegisterBus(invUpdatedBus)
+	}
+
+	invDeletedBus := event.NewBus[invoice.Deleted]()
+	{
+		_ = invDeletedBus.SetAsyncHandler(asyncHandler[invoice.Deleted](log))
+
+		invDeletedBus.Subscribe(InvalidateDashboard[invoice.Deleted](dashCache))
+		invDeletedBus.Subscribe(RefreshDashboardFeed[invoice.Deleted](dashFeed))
+
+		broker.RegisterBus(invDeletedBus)
+	}
+
+	userInvitedBus := event.NewBus[user.Invited]()
+	{
+		_ = userInvitedBus.SetAsyncHandler(asyncHandler[user.Invited](log))
+
+		userInvitedBus.SubscribeAsync(SendInvitationEmail(cfg, inviter, mailer))
+
+		broker.RegisterBus(userInvitedBus)
+	}
+
+	passwordResetRequestedBus := event.NewBus[user.PasswordResetRequested]()
+	{
+		_ = passwordResetRequestedBus.SetAsyncHandler(asyncHandler[user.PasswordResetRequested](log))
+
+		passwordResetRequestedBus.SubscribeAsync(SendPasswordResetEmail(cfg, userSvc, resetter, mailer))
+
+		broker.RegisterBus(passwordResetRequestedBus)
+	}
+
+	refreshReusedBus := event.NewBus[auth.RefreshTokenReused]()
+	{
+		_ = refreshReusedBus.SetAsyncHandler(asyncHandler[auth.RefreshTokenReused](log))
+
+		refreshReusedBus.Subscribe(LogRefreshTokenReused(log))
+
+		broker.RegisterBus(refreshReusedBus)
+	}
+
 	return RegisterInitializer{}
 }
 
@@ -51,6 +153,38 @@ func asyncHandler[T any](log logger.Logger) func(event.Handler[T]) event.Handler
 	}
 }
 
+// InvalidateDashboard drops the cached dashboard aggregates of the event's
+// tenant once the transaction publishing it commits, so a read in between
+// cannot cache the data being replaced for the whole TTL.
+func InvalidateDashboard[T any](cache *dashboard.CachedStore) event.Handler[T] {
+	return func(ctx context.Context, _ T) error {
+		db.AfterCommit(ctx, cache.Invalidate)
+		return nil
+	}
+}
+
+// RefreshDashboardFeed schedules an overview update for live dashboards
+// once the transaction publishing the event commits.
+func RefreshDashboardFeed[T any](feed *dashboard.Feed) event.Handler[T] {
+	return func(ctx context.Context, _ T) error {
+		db.AfterCommit(ctx, func(ctx context.Context) {
+			feed.Notify(ctx, uuid.Nil)
+		})
+		return nil
+	}
+}
+
+// PushNewInvoice sends a new invoice, and the overview it changes, to live
+// dashboards once the transaction creating it commits.
+func PushNewInvoice(feed *dashboard.Feed) event.Handler[invoice.Created] {
+	return func(ctx context.Context, e invoice.Created) error {
+		db.AfterCommit(ctx, func(ctx context.Context) {
+			feed.Notify(ctx, e.ID)
+		})
+		return nil
+	}
+}
+
 func SendWelcomeEmail(custSvc *customer.Service, mailer mail.Mailer) event.Handler[customer.Created] {
 	return func(ctx context.Context, e customer.Created) error {
 		cust, err := custSvc.GetByID(ctx, e.ID)
@@ -81,15 +215,174 @@ func SendWelcomeEmail(custSvc *customer.Service, mailer mail.Mailer) event.Handl
 	}
 }
 
-func SendVerifyEmail(custSvc *customer.Service) event.Handler[customer.Created] {
+func SendVerifyEmail(
+	cfg *config.Config,
+	custSvc *customer.Service,
+	verifier *customer.Verifier,
+	mailer mail.Mailer,
+) event.Handler[customer.Created] {
 	return func(ctx context.Context, e customer.Created) error {
 		cust, err := custSvc.GetByID(ctx, e.ID)
 		if err != nil {
 			return fmt.Errorf("SendVerifyEmail: get customer by id: %w", err)
 		}
 
-		fmt.Println("Email verification sent", e.ID, cust.Name)
+		if cust.IsVerified() {
+			return nil
+		}
+
+		token, err := verifier.Issue(ctx, cust.ID)
+		if err != nil {
+			return fmt.Errorf("SendVerifyEmail: issue verification: %w", err)
+		}
+
+		if err = sendVerifyEmail(ctx, cfg, mailer, cust, token); err != nil {
+			return fmt.Errorf("SendVerifyEmail: %w", err)
+		}
+		return nil
+	}
+}
+
+func ResendVerifyEmail(
+	cfg *config.Config,
+	custSvc *customer.Service,
+	verifier *customer.Verifier,
+	mailer mail.Mailer,
+) event.Handler[customer.VerificationRequested] {
+	return func(ctx context.Context, e customer.VerificationRequested) error {
+		cust, err := custSvc.GetByID(ctx, e.ID)
+		if err != nil {
+			return fmt.Errorf("ResendVerifyEmail: get customer by id: %w", err)
+		}
+
+		if cust.IsVerified() {
+			return nil
+		}
+
+		// recorded by Resend, which throttles on it
+		ver, err := verifier.Get(ctx, e.VerificationID)
+		if err != nil {
+			return fmt.Errorf("ResendVerifyEmail: get verification: %w", err)
+		}
+
+		if err = sendVerifyEmail(ctx, cfg, mailer, cust, verifier.Token(ver)); err != nil {
+			return fmt.Errorf("ResendVerifyEmail: %w", err)
+		}
+		return nil
+	}
+}
+
+func sendVerifyEmail(
+	ctx context.Context,
+	cfg *config.Config,
+	mailer mail.Mailer,
+	cust *customer.Customer,
+	token string,
+) error {
+	link := cfg.AppURL + "/api/customers/verify?token=" + url.QueryEscape(token)
+
+	m := &mail.Message{
+		From: mail.Address{
+			Name:    cfg.MailFromName,
+			Address: cfg.MailFromAddress,
+		},
+		To: []mail.Address{{
+			Name:    cust.Name,
+			Address: cust.Email,
+		}},
+		Subject: "Verify your email address",
+		HTML:    fmt.Sprintf(`<p>Please verify your email address by clicking <a href="%s">this link</a>.</p>`, link),
+		Text:    "Please verify your email address by visiting " + link,
+	}
+
+	if err := mailer.Send(ctx, m); err != nil {
+		return fmt.Errorf("send verification email: %w", err)
+	}
+
+	return nil
+}
+
+func SendInvitationEmail(cfg *config.Config, inviter *user.Inviter, mailer mail.Mailer) event.Handler[user.Invited] {
+	return func(ctx context.Context, e user.Invited) error {
+		inv, err := inviter.Get(ctx, e.ID)
+		if err != nil {
+			return fmt.Errorf("SendInvitationEmail: get invitation: %w", err)
+		}
+
+		// the frontend asks for a name and password and posts them with the token
+		link := cfg.FrontendURL + "/invitations/accept?token=" + url.QueryEscape(inviter.Token(inv))
+
+		m := &mail.Message{
+			From: mail.Address{
+				Name:    cfg.MailFromName,
+				Address: cfg.MailFromAddress,
+			},
+			To:      []mail.Address{{Address: inv.Email}},
+			Subject: "You have been invited",
+			HTML:    fmt.Sprintf(`<p>You have been invited to the dashboard. <a href="%s">Create your account</a> before %s.</p>`, link, inv.ExpiresAt.Format(time.RFC1123)),
+			Text:    "You have been invited to the dashboard. Create your account before " + inv.ExpiresAt.Format(time.RFC1123) + " by visiting " + link,
+		}
+
+		if err = mailer.Send(ctx, m); err != nil {
+			return fmt.Errorf("SendInvitationEmail: send invitation email: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func SendPasswordResetEmail(
+	cfg *config.Config,
+	userSvc *user.Service,
+	resetter *user.Resetter,
+	mailer mail.Mailer,
+) event.Handler[user.PasswordResetRequested] {
+	return func(ctx context.Context, e user.PasswordResetRequested) error {
+		reset, err := resetter.Get(ctx, e.ID)
+		if err != nil {
+			return fmt.Errorf("SendPasswordResetEmail: get password reset: %w", err)
+		}
+
+		u, err := userSvc.Get(ctx, reset.UserID)
+		if err != nil {
+			return fmt.Errorf("SendPasswordResetEmail: get user: %w", err)
+		}
+
+		// the frontend asks for the new password and posts it with the token
+		link := cfg.FrontendURL + "/password/reset?token=" + url.QueryEscape(resetter.Token(reset))
+
+		m := &mail.Message{
+			From: mail.Address{
+				Name:    cfg.MailFromName,
+				Address: cfg.MailFromAddress,
+			},
+			To: []mail.Address{{
+				Name:    u.Name,
+				Address: u.Email,
+			}},
+			Subject: "Reset your password",
+			HTML:    fmt.Sprintf(`<p><a href="%s">Choose a new password</a> before %s. If you did not ask for this, ignore this email.</p>`, link, reset.ExpiresAt.Format(time.RFC1123)),
+			Text:    "Choose a new password before " + reset.ExpiresAt.Format(time.RFC1123) + " by visiting " + link + ". If you did not ask for this, ignore this email.",
+		}
+
+		if err = mailer.Send(ctx, m); err != nil {
+			return fmt.Errorf("SendPasswordResetEmail: send password reset email: %w", err)
+		}
+
+		return nil
+	}
+}
 
+// LogRefreshTokenReused records the replay as a security event, which log
+// based alerting picks up.
+func LogRefreshTokenReused(log logger.Logger) event.Handler[auth.RefreshTokenReused] {
+	return func(ctx context.Context, e auth.RefreshTokenReused) error {
+		log.WarnContext(ctx, "refresh token reused, session family revoked",
+			"security_event", "refresh_token_reused",
+			"user_id", e.UserID,
+			"session_id", e.SessionID,
+			"family_id", e.FamilyID,
+		)
 		return nil
 	}
 }
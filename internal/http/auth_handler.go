@@ -1,15 +1,19 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 
 	himoauth "github.com/gelozr/himo/auth2"
 
+	"github.com/gelozr/go-dash/internal/app"
 	"github.com/gelozr/go-dash/internal/auth"
+	"github.com/gelozr/go-dash/internal/config"
 	"github.com/gelozr/go-dash/internal/http/request"
 	"github.com/gelozr/go-dash/internal/http/response"
 	"github.com/gelozr/go-dash/internal/http/validation"
@@ -18,13 +22,38 @@ import (
 
 type AuthHandler struct {
 	auth      himoauth.Auth
+	jwt       *auth.JWTDriver
+	userSvc   *user.Service
+	inviter   *user.Inviter
+	resetter  *user.Resetter
+	resetPass *app.ResetPassword
+	twoFactor *user.TwoFactorAuth
 	validator validation.Validator
+	// registration allows anyone to create an account
+	registration bool
 }
 
-func NewAuthHandler(auth himoauth.Auth, validator validation.Validator) *AuthHandler {
+func NewAuthHandler(
+	cfg *config.Config,
+	auth himoauth.Auth,
+	jwt *auth.JWTDriver,
+	userSvc *user.Service,
+	inviter *user.Inviter,
+	resetter *user.Resetter,
+	resetPass *app.ResetPassword,
+	twoFactor *user.TwoFactorAuth,
+	validator validation.Validator,
+) *AuthHandler {
 	return &AuthHandler{
-		auth:      auth,
-		validator: validator,
+		auth:         auth,
+		jwt:          jwt,
+		userSvc:      userSvc,
+		inviter:      inviter,
+		resetter:     resetter,
+		resetPass:    resetPass,
+		twoFactor:    twoFactor,
+		validator:    validator,
+		registration: cfg.RegistrationEnabled,
 	}
 }
 
@@ -35,7 +64,8 @@ func (h *AuthHandler) Login(c fiber.Ctx) error {
 		return fmt.Errorf("parsing login request: %w", err)
 	}
 
-	ctx := c.Context()
+	// sessions record who they were issued to
+	ctx := auth.WithClient(c.Context(), clientOf(c))
 
 	if err := h.validator.ValidateStruct(ctx, req); err != nil {
 		return fmt.Errorf("login request validation: %w", err)
@@ -56,7 +86,82 @@ func (h *AuthHandler) Login(c fiber.Ctx) error {
 		}
 	}
 
-	login, err := h.auth.Login(ctx, usr)
+	u, ok := usr.(*user.User)
+	if !ok {
+		return fmt.Errorf("unexpected user type %T", usr)
+	}
+
+	enabled, err := h.twoFactor.Enabled(ctx, u.ID)
+	if err != nil {
+		return fmt.Errorf("check two-factor: %w", err)
+	}
+
+	// tokens are only issued once a code completes the challenge
+	if enabled {
+		token, ttl, err := h.twoFactor.Challenge(ctx, u.ID)
+		if err != nil {
+			return fmt.Errorf("two-factor challenge: %w", err)
+		}
+
+		return c.JSON(
+			response.New(response.TwoFactorChallenge{
+				TwoFactorRequired: true,
+				ChallengeToken:    token,
+				ExpiresIn:         int(ttl.Seconds()),
+			}),
+		)
+	}
+
+	return h.login(ctx, c, u)
+}
+
+// LoginTwoFactor completes the login challenge with a TOTP or recovery
+// code.
+func (h *AuthHandler) LoginTwoFactor(c fiber.Ctx) error {
+	var req request.LoginTwoFactor
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing two-factor login request: %w", err)
+	}
+
+	ctx := auth.WithClient(c.Context(), clientOf(c))
+
+	if err := h.validator.ValidateStruct(ctx, req); err != nil {
+		return fmt.Errorf("two-factor login request validation: %w", err)
+	}
+
+	userID, err := h.twoFactor.Complete(ctx, req.ChallengeToken, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrChallengeExpired):
+			return fiber.NewError(fiber.StatusUnauthorized, "challenge expired")
+		case errors.Is(err, user.ErrChallengeInvalid):
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid challenge")
+		case errors.Is(err, user.ErrTwoFactorLocked):
+			return fiber.NewError(fiber.StatusTooManyRequests, "too many invalid codes, try again later")
+		case errors.Is(err, user.ErrTwoFactorCodeInvalid), errors.Is(err, user.ErrTwoFactorNotEnrolled):
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid two-factor code")
+		default:
+			return fmt.Errorf("complete two-factor login: %w", err)
+		}
+	}
+
+	u, err := h.userSvc.Get(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid challenge")
+		default:
+			return fmt.Errorf("get user: %w", err)
+		}
+	}
+
+	return h.login(ctx, c, u)
+}
+
+// login issues the tokens of the authenticated user.
+func (h *AuthHandler) login(ctx context.Context, c fiber.Ctx, u *user.User) error {
+	login, err := h.auth.Login(ctx, u)
 	if err != nil && !errors.Is(err, himoauth.ErrLoginNotSupported) {
 		switch {
 		default:
@@ -84,7 +189,8 @@ func (h *AuthHandler) Refresh(c fiber.Ctx) error {
 		return fmt.Errorf("parsing refresh request: %w", err)
 	}
 
-	ctx := c.Context()
+	// sessions record who they were issued to
+	ctx := auth.WithClient(c.Context(), clientOf(c))
 
 	if err := h.validator.ValidateStruct(ctx, req); err != nil {
 		return fmt.Errorf("refresh request validation: %w", err)
@@ -120,3 +226,158 @@ func (h *AuthHandler) Refresh(c fiber.Ctx) error {
 		response.New(res),
 	)
 }
+
+// Register creates an account when REGISTRATION_ENABLED is set. The new user
+// has no roles until an admin grants them.
+func (h *AuthHandler) Register(c fiber.Ctx) error {
+	if !h.registration {
+		return fiber.NewError(fiber.StatusNotFound, "registration is disabled.")
+	}
+
+	var req request.Register
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing register request: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("register request validation: %w", err)
+	}
+
+	u, err := h.userSvc.Create(c.Context(), req.Name, req.Email, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrEmailAlreadyTaken):
+			return validation.Errors{"email": {"email already taken"}}
+		default:
+			return fmt.Errorf("register user: %w", err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		response.New(response.ToUser(*u)),
+	)
+}
+
+// AcceptInvitation creates the invited user from the emailed token.
+func (h *AuthHandler) AcceptInvitation(c fiber.Ctx) error {
+	var req request.AcceptInvitation
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing accept invitation request: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("accept invitation request validation: %w", err)
+	}
+
+	u, err := h.inviter.Accept(c.Context(), req.Token, req.Name, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvitationTokenInvalid):
+			return fiber.NewError(fiber.StatusBadRequest, "invalid invitation token.")
+		case errors.Is(err, user.ErrInvitationTokenExpired):
+			return fiber.NewError(fiber.StatusGone, "invitation has expired.")
+		case errors.Is(err, user.ErrInvitationTokenAccepted):
+			return fiber.NewError(fiber.StatusConflict, "invitation already accepted.")
+		case errors.Is(err, user.ErrEmailAlreadyTaken):
+			return fiber.NewError(fiber.StatusConflict, "email already taken.")
+		default:
+			return fmt.Errorf("accept invitation: %w", err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		response.New(response.ToUser(*u)),
+	)
+}
+
+// ForgotPassword emails a password reset link. It answers the same whether
+// or not the email belongs to a user.
+func (h *AuthHandler) ForgotPassword(c fiber.Ctx) error {
+	var req request.ForgotPassword
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing forgot password request: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("forgot password request validation: %w", err)
+	}
+
+	if err := h.resetter.Request(c.Context(), req.Email); err != nil {
+		return fmt.Errorf("request password reset: %w", err)
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ResetPassword sets a new password with the token from the reset link and
+// signs the user out of all sessions.
+func (h *AuthHandler) ResetPassword(c fiber.Ctx) error {
+	var req request.ResetPassword
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing reset password request: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("reset password request validation: %w", err)
+	}
+
+	if err := h.resetPass.Execute(c.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, user.ErrResetTokenInvalid):
+			return fiber.NewError(fiber.StatusBadRequest, "invalid reset token.")
+		case errors.Is(err, user.ErrResetTokenExpired):
+			return fiber.NewError(fiber.StatusGone, "reset token expired.")
+		case errors.Is(err, user.ErrResetTokenUsed):
+			return fiber.NewError(fiber.StatusConflict, "reset token already used.")
+		default:
+			return fmt.Errorf("reset password: %w", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Logout ends the current session: its refresh token stops working and the
+// access token is rejected from now on.
+func (h *AuthHandler) Logout(c fiber.Ctx) error {
+	if err := h.jwt.Logout(c.Context(), bearerToken(c)); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// LogoutAll ends every session of the current user.
+func (h *AuthHandler) LogoutAll(c fiber.Ctx) error {
+	if err := h.jwt.LogoutAll(c.Context(), bearerToken(c)); err != nil {
+		return fmt.Errorf("logout all: %w", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// JWKS serves the public keys of access tokens, for other services to
+// verify them with.
+func (h *AuthHandler) JWKS(c fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(response.ToJWKS(h.jwt.Keys()))
+}
+
+// clientOf describes the client of the request, the user agent cut to what
+// is stored.
+func clientOf(c fiber.Ctx) auth.Client {
+	ua := c.Get(fiber.HeaderUserAgent)
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	return auth.Client{UserAgent: ua, IP: c.IP()}
+}
+
+func bearerToken(c fiber.Ctx) string {
+	return strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+}
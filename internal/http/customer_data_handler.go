@@ -0,0 +1,147 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/app"
+	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type CustomerDataHandler struct {
+	export    *app.ExportCustomerData
+	erase     *app.EraseCustomer
+	validator validation.Validator
+	logger    logger.Logger
+}
+
+func NewCustomerDataHandler(
+	export *app.ExportCustomerData,
+	erase *app.EraseCustomer,
+	validator validation.Validator,
+	log logger.Logger,
+) *CustomerDataHandler {
+	return &CustomerDataHandler{
+		export:    export,
+		erase:     erase,
+		validator: validator,
+		logger:    log.With("component", "http.customer_data"),
+	}
+}
+
+// Export returns everything held on a customer as JSON, or as a ZIP archive with ?format=zip.
+func (h *CustomerDataHandler) Export(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	data, err := h.export.Execute(c.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		default:
+			return fmt.Errorf("export customer data: %w", err)
+		}
+	}
+
+	res := response.ToCustomerDataExport(data)
+
+	switch c.Query("format", "json") {
+	case "json":
+		c.Attachment(fmt.Sprintf("customer-%s.json", id))
+		return c.JSON(res)
+	case "zip":
+		archive, err := zipExport(res, data.Files)
+		if err != nil {
+			return fmt.Errorf("zip customer data: %w", err)
+		}
+
+		c.Attachment(fmt.Sprintf("customer-%s.zip", id))
+		c.Set(fiber.HeaderContentType, "application/zip")
+		return c.Send(archive)
+	default:
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "format must be one of json, zip.")
+	}
+}
+
+func (h *CustomerDataHandler) Erase(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	var req request.EraseCustomer
+
+	if err = c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("erase customer bind request body: %w", err)
+	}
+
+	if err = h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("erase customer validation: %w", err)
+	}
+
+	requestedBy, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthenticated.")
+	}
+
+	erasure, err := h.erase.Execute(c.Context(), id, requestedBy, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		case errors.Is(err, customer.ErrCustomerErased):
+			return fiber.NewError(fiber.StatusConflict, "customer already erased.")
+		default:
+			return fmt.Errorf("erase customer: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToCustomerErasure(*erasure)),
+	)
+}
+
+func zipExport(res response.CustomerDataExport, files []app.ExportFile) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create("data.json")
+	if err != nil {
+		return nil, fmt.Errorf("create data.json: %w", err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(res); err != nil {
+		return nil, fmt.Errorf("encode data.json: %w", err)
+	}
+
+	for _, f := range files {
+		w, err = zw.Create("files/" + f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %w", f.Name, err)
+		}
+		if _, err = w.Write(f.Data); err != nil {
+			return nil, fmt.Errorf("write %s: %w", f.Name, err)
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return nil, fmt.Errorf("close zip: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -8,24 +8,40 @@ import (
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/app"
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/http/request"
 	"github.com/gelozr/go-dash/internal/http/response"
 	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/imaging"
 	"github.com/gelozr/go-dash/internal/logger"
 )
 
 type CustomerHandler struct {
-	svc       *customer.Service
-	validator validation.Validator
-	logger    logger.Logger
+	svc          *customer.Service
+	verifier     *customer.Verifier
+	uploadAvatar *app.UploadAvatar
+	cfSvc        *customfield.Service
+	validator    validation.Validator
+	logger       logger.Logger
 }
 
-func NewCustomerHandler(svc *customer.Service, validator validation.Validator, log logger.Logger) *CustomerHandler {
+func NewCustomerHandler(
+	svc *customer.Service,
+	verifier *customer.Verifier,
+	uploadAvatar *app.UploadAvatar,
+	cfSvc *customfield.Service,
+	validator validation.Validator,
+	log logger.Logger,
+) *CustomerHandler {
 	return &CustomerHandler{
-		svc:       svc,
-		validator: validator,
-		logger:    log.With("component", "http.customer"),
+		svc:          svc,
+		verifier:     verifier,
+		uploadAvatar: uploadAvatar,
+		cfSvc:        cfSvc,
+		validator:    validator,
+		logger:       log.With("component", "http.customer"),
 	}
 }
 
@@ -73,12 +89,18 @@ func (h *CustomerHandler) Create(c fiber.Ctx) error {
 		return fmt.Errorf("creaate customer bind request body: %w", err)
 	}
 
-	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+	err := h.validator.ValidateStruct(c.Context(), req)
+	if err != nil {
 		return fmt.Errorf("create customer validation: %w", err)
 	}
 
 	reqCust := req.ToCustomer()
 
+	reqCust.CustomFields, err = validateCustomFields(c.Context(), h.cfSvc, h.validator, customfield.EntityCustomer, req.CustomFields)
+	if err != nil {
+		return fmt.Errorf("create customer validate custom fields: %w", err)
+	}
+
 	cust, err := h.svc.Create(c.Context(), reqCust)
 	if err != nil {
 		switch {
@@ -95,8 +117,17 @@ func (h *CustomerHandler) Create(c fiber.Ctx) error {
 }
 
 func (h *CustomerHandler) SearchWithInvoiceInfo(c fiber.Ctx) error {
-	search := c.Query("search")
-	result, err := h.svc.SearchWithInvoiceInfo(c.Context(), search)
+	cf, err := h.cfSvc.ResolveFilters(c.Context(), customfield.EntityCustomer, customFieldFilters(c))
+	if err != nil {
+		return fmt.Errorf("resolve custom field filters: %w", customFieldErrors(err, "cf."))
+	}
+
+	filter := customer.SearchFilter{
+		Text:         c.Query("search"),
+		CustomFields: cf,
+	}
+
+	result, err := h.svc.SearchWithInvoiceInfo(c.Context(), filter)
 	if err != nil {
 		return fmt.Errorf("search customer with invoice info: %w", err)
 	}
@@ -105,3 +136,124 @@ func (h *CustomerHandler) SearchWithInvoiceInfo(c fiber.Ctx) error {
 		response.New(response.ToCustomerWithInvoiceInfoList(result)),
 	)
 }
+
+func (h *CustomerHandler) Verify(c fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "missing verification token.")
+	}
+
+	cust, err := h.verifier.Verify(c.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrVerificationTokenInvalid),
+			errors.Is(err, customer.ErrVerificationTokenUsed):
+			return fiber.NewError(fiber.StatusBadRequest, "invalid verification token.")
+		case errors.Is(err, customer.ErrVerificationTokenExpired):
+			return fiber.NewError(fiber.StatusGone, "verification token expired.")
+		default:
+			return fmt.Errorf("verify customer: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToCustomer(*cust)),
+	)
+}
+
+func (h *CustomerHandler) ResendVerification(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	if err = h.verifier.Resend(c.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		case errors.Is(err, customer.ErrAlreadyVerified):
+			return fiber.NewError(fiber.StatusConflict, "customer already verified.")
+		case errors.Is(err, customer.ErrVerificationThrottled):
+			return fiber.NewError(fiber.StatusTooManyRequests, "verification email recently sent, try again later.")
+		default:
+			return fmt.Errorf("resend verification: %w", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *CustomerHandler) UploadAvatar(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	fh, err := c.FormFile("avatar")
+	if err != nil {
+		return validation.Errors{"avatar": {"avatar is a required field"}}
+	}
+
+	if fh.Size > app.MaxAvatarSize {
+		return validation.Errors{"avatar": {fmt.Sprintf("avatar must not be larger than %d KB", app.MaxAvatarSize>>10)}}
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return fmt.Errorf("open avatar: %w", err)
+	}
+	defer f.Close()
+
+	cust, err := h.uploadAvatar.Execute(c.Context(), id, f)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		case errors.Is(err, customer.ErrCustomerErased):
+			return fiber.NewError(fiber.StatusConflict, "customer is erased.")
+		case errors.Is(err, imaging.ErrUnsupportedType):
+			return validation.Errors{"avatar": {"avatar must be a JPEG, PNG or GIF image"}}
+		case errors.Is(err, imaging.ErrTooLarge):
+			return validation.Errors{"avatar": {"avatar image is too large"}}
+		default:
+			return fmt.Errorf("upload avatar: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToCustomer(*cust)),
+	)
+}
+
+func (h *CustomerHandler) SetCustomFields(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	var req request.SetCustomFields
+	if err = c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("set custom fields bind request body: %w", err)
+	}
+
+	values, err := validateCustomFields(c.Context(), h.cfSvc, h.validator, customfield.EntityCustomer, req.CustomFields)
+	if err != nil {
+		return fmt.Errorf("set custom fields validation: %w", err)
+	}
+
+	cust, err := h.svc.SetCustomFields(c.Context(), id, values)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		case errors.Is(err, customer.ErrCustomerErased):
+			return fiber.NewError(fiber.StatusConflict, "customer is erased.")
+		default:
+			return fmt.Errorf("set custom fields: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToCustomer(*cust)),
+	)
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customfield"
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type CustomFieldHandler struct {
+	svc       *customfield.Service
+	validator validation.Validator
+	logger    logger.Logger
+}
+
+func NewCustomFieldHandler(svc *customfield.Service, validator validation.Validator, log logger.Logger) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		svc:       svc,
+		validator: validator,
+		logger:    log.With("component", "http.customfield"),
+	}
+}
+
+func (h *CustomFieldHandler) List(c fiber.Ctx) error {
+	entity := customfield.Entity(c.Query("entity"))
+	if !entity.Valid() {
+		return validation.Errors{"entity": {"entity must be one of customer, invoice"}}
+	}
+
+	defs, err := h.svc.List(c.Context(), entity)
+	if err != nil {
+		return fmt.Errorf("list custom fields: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToList(defs, response.ToCustomFieldDefinition)),
+	)
+}
+
+func (h *CustomFieldHandler) Create(c fiber.Ctx) error {
+	var req request.CreateCustomField
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("create custom field bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("create custom field validation: %w", err)
+	}
+
+	def, err := h.svc.Create(c.Context(), req.ToDefinition())
+	if err != nil {
+		switch {
+		case errors.Is(err, customfield.ErrKeyAlreadyTaken):
+			return fiber.NewError(fiber.StatusConflict, "custom field key already taken.")
+		default:
+			return fmt.Errorf("create custom field: %w", customFieldErrors(err, ""))
+		}
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.New(response.ToCustomFieldDefinition(*def)),
+	)
+}
+
+func (h *CustomFieldHandler) Delete(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	if err = h.svc.Delete(c.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, customfield.ErrDefinitionNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "custom field not found.")
+		default:
+			return fmt.Errorf("delete custom field: %w", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// customFieldFilters collects "?cf.<key>=<value>" query parameters.
+func customFieldFilters(c fiber.Ctx) customfield.Filters {
+	var filters customfield.Filters
+
+	for k, v := range c.Queries() {
+		key, ok := strings.CutPrefix(k, "cf.")
+		if !ok || key == "" {
+			continue
+		}
+		if filters == nil {
+			filters = make(customfield.Filters)
+		}
+		filters[key] = v
+	}
+
+	return filters
+}
+
+// customFieldErrors maps a customfield.FieldError to validation errors with
+// the prefix added to its fields. Other errors are returned as they are.
+func customFieldErrors(err error, prefix string) error {
+	var fErr *customfield.FieldError
+	if !errors.As(err, &fErr) {
+		return err
+	}
+
+	errs := make(validation.Errors, len(fErr.Fields))
+	for field, msgs := range fErr.Fields {
+		errs[prefix+field] = msgs
+	}
+
+	return errs
+}
+
+// validateCustomFields checks values against the entity's definitions and
+// their rules, and returns them normalised. Errors are reported as
+// validation.Errors keyed "custom_fields.<key>".
+func validateCustomFields(
+	ctx context.Context,
+	cfSvc *customfield.Service,
+	validator validation.Validator,
+	entity customfield.Entity,
+	values customfield.Values,
+) (customfield.Values, error) {
+	out, rules, err := cfSvc.Validate(ctx, entity, values)
+	if err != nil {
+		return nil, customFieldErrors(err, "custom_fields.")
+	}
+
+	if err = validator.ValidateMap(ctx, out, rules); err != nil {
+		var vErrs validation.Errors
+		if !errors.As(err, &vErrs) {
+			return nil, fmt.Errorf("validate custom fields: %w", err)
+		}
+
+		errs := make(validation.Errors, len(vErrs))
+		for field, msgs := range vErrs {
+			errs["custom_fields."+field] = msgs
+		}
+		return nil, errs
+	}
+
+	return out, nil
+}
@@ -1,12 +1,15 @@
 package http
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 
 	"github.com/gelozr/go-dash/internal/dashboard"
 	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
 	"github.com/gelozr/go-dash/internal/logger"
 )
 
@@ -22,24 +25,193 @@ func NewDashboardHandler(svc *dashboard.Service, log logger.Logger) *DashboardHa
 	}
 }
 
+// GetOverview serves ?period=all|month|quarter|ytd (default all), comparing
+// month, quarter and ytd with the same span of the previous period. An
+// explicit ?from=&to= range is compared with the equally long range before it.
 func (h *DashboardHandler) GetOverview(c fiber.Ctx) error {
-	o, err := h.svc.GetOverview(c.Context())
+	period, cur, prev, err := periodRange(c, dashboard.PeriodAll)
 	if err != nil {
-		return fmt.Errorf("get overview: %w", err)
+		return err
+	}
+
+	if cur.IsZero() {
+		o, err := h.svc.GetOverview(c.Context(), cur)
+		if err != nil {
+			return fmt.Errorf("get overview: %w", err)
+		}
+
+		return c.JSON(
+			response.New(response.ToOverviewComparison(string(period), &dashboard.OverviewComparison{Current: *o})),
+		)
+	}
+
+	cmp, err := h.svc.CompareOverview(c.Context(), cur, prev)
+	if err != nil {
+		return fmt.Errorf("compare overview: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToOverviewComparison(string(period), cmp)),
+	)
+}
+
+// GetKPIs serves the financial KPIs for ?period=month|quarter|ytd or an
+// explicit ?from=&to= range, defaulting to the last 90 days.
+func (h *DashboardHandler) GetKPIs(c fiber.Ctx) error {
+	var (
+		r   dashboard.Range
+		err error
+	)
+
+	if c.Query("period") == "" && c.Query("from") == "" && c.Query("to") == "" {
+		today := dashboard.GranularityDay.Truncate(now(c))
+		r = dashboard.Range{From: today.AddDate(0, 0, -89), To: today.AddDate(0, 0, 1)}
+	} else {
+		_, r, _, err = periodRange(c, dashboard.PeriodAll)
+		if err != nil {
+			return err
+		}
+		if r.IsZero() {
+			return validation.Errors{"period": {"period must be one of month, quarter, ytd"}}
+		}
+	}
+
+	k, err := h.svc.GetKPIs(c.Context(), r)
+	if err != nil {
+		return fmt.Errorf("get kpis: %w", err)
 	}
 
 	return c.JSON(
-		response.New(response.ToOverview(o)),
+		response.New(response.ToKPIs(k)),
 	)
 }
 
-func (h *DashboardHandler) GetMonthlyRevenues(c fiber.Ctx) error {
-	revs, err := h.svc.GetMonthlyRevenues(c.Context())
+// GetTopCustomers serves ?metric=paid|pending|count (default paid) and
+// ?limit= (default 5, at most 100) over ?period= or ?from=&to=.
+func (h *DashboardHandler) GetTopCustomers(c fiber.Ctx) error {
+	metric := dashboard.Metric(c.Query("metric", string(dashboard.MetricPaid)))
+	if !metric.Valid() {
+		return validation.Errors{"metric": {"metric must be one of paid, pending, count"}}
+	}
+
+	limit := getDefaultNum(c.Query("limit"), 5)
+	if limit < 1 || limit > 100 {
+		return validation.Errors{"limit": {"limit must be between 1 and 100"}}
+	}
+
+	_, r, _, err := periodRange(c, dashboard.PeriodAll)
+	if err != nil {
+		return err
+	}
+
+	top, err := h.svc.GetTopCustomers(c.Context(), dashboard.TopCustomersQuery{
+		Metric: metric,
+		Limit:  limit,
+		Range:  r,
+	})
+	if err != nil {
+		return fmt.Errorf("get top customers: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToTopCustomers(metric, r, top)),
+	)
+}
+
+// GetForecast serves the revenue forecast for the next ?months= (default 3)
+// months.
+func (h *DashboardHandler) GetForecast(c fiber.Ctx) error {
+	months := getDefaultNum(c.Query("months"), 3)
+	if months < 1 || months > dashboard.MaxForecastMonths {
+		return validation.Errors{"months": {fmt.Sprintf("months must be between 1 and %d", dashboard.MaxForecastMonths)}}
+	}
+
+	f, err := h.svc.GetForecast(c.Context(), now(c), months)
 	if err != nil {
-		return fmt.Errorf("get monthly revenues: %w", err)
+		return fmt.Errorf("get forecast: %w", err)
 	}
 
 	return c.JSON(
-		response.New(response.ToMonthlyRevenueList(revs)),
+		response.New(response.ToForecast(f)),
 	)
 }
+
+// GetCohorts serves the cohort matrix for cohorts acquired from ?from= to
+// ?to= (YYYY-MM-DD, default the last 12 months).
+func (h *DashboardHandler) GetCohorts(c fiber.Ctx) error {
+	t := now(c)
+	from, to, err := dateRange(c, time.Date(t.Year(), t.Month()-11, 1, 0, 0, 0, 0, t.Location()), t)
+	if err != nil {
+		return err
+	}
+
+	if to.Sub(from) > 10*365*24*time.Hour {
+		return validation.Errors{"from": {"range must not span more than 10 years"}}
+	}
+
+	cohorts, err := h.svc.GetCohorts(c.Context(), from, to)
+	if err != nil {
+		return fmt.Errorf("get cohorts: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToCohortList(cohorts)),
+	)
+}
+
+// GetRevenues serves ?from=&to= (YYYY-MM-DD, default the last 12 months)
+// and ?granularity=day|week|month|quarter (default month).
+func (h *DashboardHandler) GetRevenues(c fiber.Ctx) error {
+	t := now(c)
+	from, to, err := dateRange(c, time.Date(t.Year(), t.Month()-11, 1, 0, 0, 0, 0, t.Location()), t)
+	if err != nil {
+		return err
+	}
+
+	g := dashboard.Granularity(c.Query("granularity", string(dashboard.GranularityMonth)))
+	if !g.Valid() {
+		return validation.Errors{"granularity": {"granularity must be one of day, week, month, quarter"}}
+	}
+
+	revs, err := h.svc.GetRevenues(c.Context(), dashboard.RevenueQuery{From: from, To: to, Granularity: g})
+	if err != nil {
+		switch {
+		case errors.Is(err, dashboard.ErrTooManyBuckets):
+			return validation.Errors{"granularity": {fmt.Sprintf("range must not span more than %d %ss", dashboard.MaxRevenueBuckets, g)}}
+		default:
+			return fmt.Errorf("get revenues: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToRevenueList(revs, g)),
+	)
+}
+
+// periodRange reads ?period= or an explicit ?from=&to= range, returning
+// the current range and the one it is compared with.
+func periodRange(c fiber.Ctx, def dashboard.Period) (dashboard.Period, dashboard.Range, dashboard.Range, error) {
+	period := dashboard.Period(c.Query("period", string(def)))
+	if !period.Valid() {
+		return "", dashboard.Range{}, dashboard.Range{}, validation.Errors{"period": {"period must be one of all, month, quarter, ytd"}}
+	}
+
+	// whole minutes keep to-date ranges cacheable between requests
+	cur, prev := period.Ranges(now(c).Truncate(time.Minute))
+
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := dateRange(c, time.Time{}, dashboard.GranularityDay.Truncate(now(c)))
+		if err != nil {
+			return "", dashboard.Range{}, dashboard.Range{}, err
+		}
+		if from.IsZero() {
+			return "", dashboard.Range{}, dashboard.Range{}, validation.Errors{"from": {"from is required with to"}}
+		}
+
+		period = dashboard.PeriodCustom
+		cur = dashboard.Range{From: from, To: to.AddDate(0, 0, 1)}
+		prev = cur.Previous()
+	}
+
+	return period, cur, prev, nil
+}
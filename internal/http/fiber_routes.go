@@ -1,7 +1,16 @@
 package http
 
 import (
+	"fmt"
+
 	auth "github.com/gelozr/himo/auth2"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/static"
+	"github.com/valyala/fasthttp/expvarhandler"
+
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/storage"
+	"github.com/gelozr/go-dash/internal/tenant"
 )
 
 type RouteInitializer struct{}
@@ -13,49 +22,164 @@ func SetupFiberRoutes(
 	dashH *DashboardHandler,
 	userH *UserHandler,
 	custH *CustomerHandler,
+	custDataH *CustomerDataHandler,
+	stmtH *StatementHandler,
 	invH *InvoiceHandler,
-) RouteInitializer {
+	cfH *CustomFieldHandler,
+	streamH *StreamHandler,
+	layoutH *LayoutHandler,
+	roleH *RoleHandler,
+	sessionH *SessionHandler,
+	twoFactorH *TwoFactorHandler,
+	tenantH *TenantHandler,
+	tenants *tenant.Service,
+) (RouteInitializer, error) {
 
-	r := s.app.Group("/api")
+	// every route declares a policy; business groups require a token, and
+	// those reading customer data a tenant
+	r := NewPolicyRouter(s.app, "/api", auth, tenants)
+	jwt := Authenticated("jwt")
+	tjwt := jwt.InTenant()
 
 	// auth routes
-	ag := r.Group("/auth", loggerKeyMiddleware("http.auth"), rateLimiter(5))
+	// credential endpoints are limited each on their own, so refreshing or
+	// managing sessions does not use up the budget of logging in
+	ag := r.Group("/auth", Public, loggerKeyMiddleware("http.auth"))
 	{
-		ag.Post("/login", authH.Login)
+		ag.Post("/login", authH.Login, rateLimiter(5))
+		ag.Post("/login/two-factor", authH.LoginTwoFactor, rateLimiter(5))
 		ag.Post("/refresh", authH.Refresh)
+		ag.Post("/register", authH.Register, rateLimiter(5))
+		ag.Post("/invitations/accept", authH.AcceptInvitation, rateLimiter(5))
+		ag.Post("/password/forgot", authH.ForgotPassword, rateLimiter(5))
+		ag.Post("/password/reset", authH.ResetPassword, rateLimiter(5))
+		ag.With(jwt).Post("/logout", authH.Logout)
+		ag.With(jwt).Post("/logout-all", authH.LogoutAll)
+		ag.With(jwt).Get("/sessions", sessionH.List)
+		ag.With(jwt).Delete("/sessions/:id", sessionH.Revoke)
+		ag.With(jwt).Get("/two-factor", twoFactorH.Status)
+		ag.With(jwt).Post("/two-factor", twoFactorH.Enroll)
+		ag.With(jwt).Post("/two-factor/confirm", twoFactorH.Confirm)
+		ag.With(jwt).Post("/two-factor/disable", twoFactorH.Disable)
 	}
 
 	// dashboard routes
-	// dg := r.Group("/dash", loggerKeyMiddleware("http.dashboard"), AuthMiddleware(authSvc))
-	dg := r.Group("/dash", loggerKeyMiddleware("http.dashboard"), AuthMiddleware(auth, "jwt"))
+	dg := r.Group("/dash", tjwt.Require(rbac.ReportsRead), loggerKeyMiddleware("http.dashboard"))
 	{
 		dg.Get("/overview", dashH.GetOverview)
-		dg.Get("/revenues", dashH.GetMonthlyRevenues)
+		dg.Get("/revenues", dashH.GetRevenues)
+		dg.Get("/kpis", dashH.GetKPIs)
+		dg.Get("/top-customers", dashH.GetTopCustomers)
+		dg.Get("/forecast", dashH.GetForecast)
+		dg.Get("/cohorts", dashH.GetCohorts)
+		dg.Get("/layout", layoutH.Get)
+		dg.Put("/layout", layoutH.Save, rateLimiter(30))
+
+		// EventSource cannot send headers, so the token and tenant may come in the query
+		dg.Get("/stream", streamH.Dashboard, QueryToken())
 	}
 
 	// user routes
-	r.Get("/users/email/:email", userH.GetByEmail, loggerKeyMiddleware("http.user"))
+	ug := r.Group("/users", jwt, loggerKeyMiddleware("http.user"))
+	ur := ug.With(jwt.Require(rbac.UsersRead))
+	uw := ug.With(jwt.Require(rbac.UsersWrite))
+	{
+		ur.Get("/", userH.List)
+		ur.Get("/email/:email", userH.GetByEmail)
+		ug.Put("/me/timezone", userH.SetTimezone, rateLimiter(30))
+		uw.Post("/", userH.Create, rateLimiter(30))
+		uw.Post("/invitations", userH.Invite, rateLimiter(10))
+		ur.Get("/:id", userH.Get)
+		uw.Patch("/:id", userH.Update, rateLimiter(30))
+		ug.With(jwt.Require(rbac.UsersDelete)).Delete("/:id", userH.Delete, rateLimiter(30))
+
+		// role assignments
+		ug.With(jwt.Require(rbac.RolesManage)).Get("/:id/roles", roleH.GetUserRoles)
+		ug.With(jwt.Require(rbac.RolesManage)).Put("/:id/roles", roleH.SetUserRoles, rateLimiter(30))
+
+		// signed in devices
+		ug.With(jwt.Require(rbac.SessionsManage)).Get("/:id/sessions", sessionH.ListForUser)
+		ug.With(jwt.Require(rbac.SessionsManage)).Delete("/:id/sessions/:sessionId", sessionH.RevokeForUser, rateLimiter(30))
+	}
+
+	r.Group("/roles", jwt.Require(rbac.RolesManage), loggerKeyMiddleware("http.role")).Get("/", roleH.List)
+
+	// tenant routes
+	tg := r.Group("/tenants", jwt, loggerKeyMiddleware("http.tenant"))
+	tm := tg.With(jwt.Require(rbac.TenantsManage))
+	{
+		tg.Get("/", tenantH.List)
+		tm.Post("/", tenantH.Create, rateLimiter(10))
+		tm.Put("/:id/members/:userId", tenantH.AddMember, rateLimiter(30))
+		tm.Delete("/:id/members/:userId", tenantH.RemoveMember, rateLimiter(30))
+	}
 
 	// customer routes
-	cg := r.Group("/customers", loggerKeyMiddleware("http.customer"))
+	cg := r.Group("/customers", tjwt.Require(rbac.CustomersRead), loggerKeyMiddleware("http.customer"))
+	cw := cg.With(tjwt.Require(rbac.CustomersWrite))
 	{
 		cg.Get("/", custH.List)
 		cg.Get("/filtered", custH.SearchWithInvoiceInfo)
+		// opened by customers from the verification email
+		cg.With(Public).Get("/verify", custH.Verify, rateLimiter(10))
 		cg.Get("/:id", custH.Get)
-		cg.Post("/", custH.Create, rateLimiter(30))
+		cw.Post("/", custH.Create, rateLimiter(30))
+		cw.Post("/:id/verification", custH.ResendVerification, rateLimiter(5))
+		cw.Post("/:id/avatar", custH.UploadAvatar, rateLimiter(10))
+		cw.Put("/:id/custom-fields", custH.SetCustomFields, rateLimiter(30))
+		cg.With(tjwt.Require(rbac.InvoicesRead)).Get("/:id/statement", stmtH.Get)
+		cg.With(tjwt.Require(rbac.InvoicesRead)).Post("/:id/statement/email", stmtH.Email, rateLimiter(5))
+
+		// data subject requests
+		cg.Get("/:id/export", custDataH.Export, rateLimiter(5))
+		cg.With(tjwt.Require(rbac.CustomersDelete)).Post("/:id/erase", custDataH.Erase, rateLimiter(5))
 	}
 
 	// invoice routes
-	ig := r.Group("/invoices", loggerKeyMiddleware("http.invoice"))
+	ig := r.Group("/invoices", tjwt.Require(rbac.InvoicesRead), loggerKeyMiddleware("http.invoice"))
+	iw := ig.With(tjwt.Require(rbac.InvoicesWrite))
 	{
 		ig.Get("/latest", invH.GetLatest)
 		ig.Get("/filtered", invH.Search)
 
 		ig.Get("/:id", invH.Get)
-		ig.Post("/", invH.Create, rateLimiter(30))
-		ig.Patch("/:id", invH.Update, rateLimiter(30))
-		ig.Delete("/:id", invH.Delete, rateLimiter(30))
+		iw.Post("/", invH.Create, rateLimiter(30))
+		iw.Patch("/:id", invH.Update, rateLimiter(30))
+		ig.With(tjwt.Require(rbac.InvoicesDelete)).Delete("/:id", invH.Delete, rateLimiter(30))
 	}
 
-	return RouteInitializer{}
+	// custom field definitions
+	fg := r.Group("/custom-fields", tjwt, loggerKeyMiddleware("http.customfield"))
+	{
+		fg.Get("/", cfH.List)
+		fg.With(tjwt.Require(rbac.CustomFieldsWrite)).Post("/", cfH.Create, rateLimiter(30))
+		fg.With(tjwt.Require(rbac.CustomFieldsWrite)).Delete("/:id", cfH.Delete, rateLimiter(30))
+	}
+
+	// runtime and cache counters, for operators only
+	if s.cfg.AppDebug {
+		r.Group("/debug", jwt.Require(rbac.SystemMonitor)).Get("/vars", debugVars)
+	}
+
+	// access token keys, at the well-known path verifiers look for
+	s.app.Get("/.well-known/jwks.json", authH.JWKS)
+	r.Declare(fiber.MethodGet, "/.well-known/jwks.json", Public)
+
+	// locally stored files
+	if s.cfg.StorageDriver == "" || s.cfg.StorageDriver == string(storage.Local) {
+		s.app.Get("/storage*", static.New(s.cfg.StorageLocalPath))
+		r.Declare(fiber.MethodGet, "/storage*", Public)
+	}
+
+	if err := r.Verify(s.app); err != nil {
+		return RouteInitializer{}, fmt.Errorf("route policies: %w", err)
+	}
+
+	return RouteInitializer{}, nil
+}
+
+// debugVars serves the expvar counters as JSON.
+func debugVars(c fiber.Ctx) error {
+	expvarhandler.ExpvarHandler(c.RequestCtx())
+	return nil
 }
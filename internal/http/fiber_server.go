@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
@@ -20,6 +21,10 @@ import (
 type FiberServer struct {
 	cfg *config.Config
 	app *fiber.App
+
+	// done is closed on Shutdown so long-lived streams can end
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewFiberServer(
@@ -37,12 +42,18 @@ func NewFiberServer(
 	// app.Use(logger.New())
 	app.Use(recover.New(recover.Config{}))
 	app.Use(RequestLocale())
+	app.Use(RequestTimezone(cfg.Location))
 	app.Use(RequestID())
 	app.Use(cors.New(cors.Config{}))
 	app.Use(limiter.New(limiter.Config{Max: 60}))
 	app.Use(ValidationResponse())
 
-	return &FiberServer{cfg, app}
+	return &FiberServer{cfg: cfg, app: app, done: make(chan struct{})}
+}
+
+// Done is closed when the server starts shutting down.
+func (f *FiberServer) Done() <-chan struct{} {
+	return f.done
 }
 
 func (f *FiberServer) Serve() error {
@@ -53,6 +64,9 @@ func (f *FiberServer) Serve() error {
 }
 
 func (f *FiberServer) Shutdown(ctx context.Context) error {
+	// end open streams first, shutdown waits for their connections to close
+	f.closeOnce.Do(func() { close(f.done) })
+
 	if err := f.app.ShutdownWithContext(ctx); err != nil {
 		return fmt.Errorf("fiber shutdown: %w", err)
 	}
@@ -3,8 +3,13 @@ package http
 import (
 	"context"
 	"strconv"
+	"time"
 
+	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/user"
 )
 
 type Response struct {
@@ -33,6 +38,25 @@ func UserIDFromCtx(ctx context.Context) (uuid.UUID, bool) {
 	return v, ok
 }
 
+// UserFromCtx returns the authenticated user with their roles and permissions.
+func UserFromCtx(ctx context.Context) (user.User, bool) {
+	v, ok := ctx.Value(userCtxKey).(user.User)
+	return v, ok
+}
+
+// LocationFromCtx returns the timezone of the request, UTC when none was set.
+func LocationFromCtx(ctx context.Context) *time.Location {
+	if v, ok := ctx.Value(reqTZCtxKey).(*time.Location); ok {
+		return v
+	}
+	return time.UTC
+}
+
+// now is the current time in the timezone of the request.
+func now(c fiber.Ctx) time.Time {
+	return time.Now().In(LocationFromCtx(c.Context()))
+}
+
 func getDefaultNum[T any](value string, def T) T {
 	switch any(def).(type) {
 	case int, int8, int16, int32, int64:
@@ -51,3 +75,35 @@ func getDefaultNum[T any](value string, def T) T {
 		return def
 	}
 }
+
+// dateRange reads ?from= and ?to= as YYYY-MM-DD in the request timezone,
+// falling back to the given defaults when absent.
+func dateRange(c fiber.Ctx, from, to time.Time) (time.Time, time.Time, error) {
+	errs := validation.Errors{}
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, LocationFromCtx(c.Context()))
+		if err != nil {
+			errs["from"] = append(errs["from"], "from must be a date in the format YYYY-MM-DD")
+		}
+		from = t
+	}
+
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, LocationFromCtx(c.Context()))
+		if err != nil {
+			errs["to"] = append(errs["to"], "to must be a date in the format YYYY-MM-DD")
+		}
+		to = t
+	}
+
+	if len(errs) == 0 && to.Before(from) {
+		errs["to"] = append(errs["to"], "to must not be before from")
+	}
+
+	if len(errs) > 0 {
+		return time.Time{}, time.Time{}, errs
+	}
+
+	return from, to, nil
+}
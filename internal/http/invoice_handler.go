@@ -10,17 +10,20 @@ import (
 
 	"github.com/gelozr/go-dash/internal/app"
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/http/request"
 	"github.com/gelozr/go-dash/internal/http/response"
 	"github.com/gelozr/go-dash/internal/http/validation"
 	"github.com/gelozr/go-dash/internal/invoice"
 	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/optional"
 )
 
 type InvoiceHandler struct {
 	invSvc        *invoice.Service
 	createInvoice *app.CreateInvoice
+	cfSvc         *customfield.Service
 	validator     validation.Validator
 	logger        logger.Logger
 }
@@ -28,12 +31,14 @@ type InvoiceHandler struct {
 func NewInvoiceHandler(
 	invSvc *invoice.Service,
 	createInvoice *app.CreateInvoice,
+	cfSvc *customfield.Service,
 	validator validation.Validator,
 	logger logger.Logger,
 ) *InvoiceHandler {
 	return &InvoiceHandler{
 		invSvc:        invSvc,
 		createInvoice: createInvoice,
+		cfSvc:         cfSvc,
 		validator:     validator,
 		logger:        logger.With("component", "http.invoice"),
 	}
@@ -77,9 +82,15 @@ func (h *InvoiceHandler) Search(c fiber.Ctx) error {
 
 	p := listing.NewPage(page, size)
 
+	cf, err := h.cfSvc.ResolveFilters(c.Context(), customfield.EntityInvoice, customFieldFilters(c))
+	if err != nil {
+		return fmt.Errorf("resolve custom field filters: %w", customFieldErrors(err, "cf."))
+	}
+
 	filter := invoice.SearchFilter{
-		Text: c.Query("search"),
-		Sort: listing.SortLatest,
+		Text:         c.Query("search"),
+		Sort:         listing.SortLatest,
+		CustomFields: cf,
 	}
 
 	result, err := h.invSvc.Search(c.Context(), filter, p)
@@ -108,6 +119,11 @@ func (h *InvoiceHandler) Create(c fiber.Ctx) error {
 		return fmt.Errorf("create invoice to dto: %w", err)
 	}
 
+	reqInv.CustomFields, err = validateCustomFields(c.Context(), h.cfSvc, h.validator, customfield.EntityInvoice, req.CustomFields)
+	if err != nil {
+		return fmt.Errorf("create invoice validate custom fields: %w", err)
+	}
+
 	inv, err := h.createInvoice.Execute(c.Context(), reqInv)
 	if err != nil {
 		switch {
@@ -143,6 +159,14 @@ func (h *InvoiceHandler) Update(c fiber.Ctx) error {
 		return err
 	}
 
+	if updateInput.CustomFields.IsPresent {
+		values, err := validateCustomFields(c.Context(), h.cfSvc, h.validator, customfield.EntityInvoice, updateInput.CustomFields.Val)
+		if err != nil {
+			return fmt.Errorf("update invoice validate custom fields: %w", err)
+		}
+		updateInput.CustomFields = optional.Of(values)
+	}
+
 	inv, err := h.invSvc.Update(c.Context(), id, updateInput)
 	if err != nil {
 		switch {
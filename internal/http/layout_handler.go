@@ -0,0 +1,78 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/gelozr/go-dash/internal/dashboard"
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type LayoutHandler struct {
+	svc       *dashboard.LayoutService
+	validator validation.Validator
+	logger    logger.Logger
+}
+
+func NewLayoutHandler(svc *dashboard.LayoutService, validator validation.Validator, log logger.Logger) *LayoutHandler {
+	return &LayoutHandler{
+		svc:       svc,
+		validator: validator,
+		logger:    log.With("component", "http.dashboard.layout"),
+	}
+}
+
+// Get serves the authenticated user's layout, or the default one.
+func (h *LayoutHandler) Get(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	l, err := h.svc.Get(c.Context(), userID)
+	if err != nil {
+		return fmt.Errorf("get layout: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToLayout(l)),
+	)
+}
+
+// Save replaces the authenticated user's layout.
+func (h *LayoutHandler) Save(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	var req request.SaveLayout
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("save layout bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("save layout validation: %w", err)
+	}
+
+	l, err := h.svc.Save(c.Context(), userID, req.ToWidgets())
+	if err != nil {
+		var lErr *dashboard.LayoutError
+		switch {
+		case errors.As(err, &lErr):
+			return validation.Errors(lErr.Fields)
+		default:
+			return fmt.Errorf("save layout: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToLayout(l)),
+	)
+}
@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
 	himoauth "github.com/gelozr/himo/auth2"
 	"github.com/gofiber/fiber/v3"
@@ -16,6 +16,9 @@ import (
 	"github.com/gelozr/go-dash/internal/auth"
 	"github.com/gelozr/go-dash/internal/http/response"
 	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/tenant"
+	"github.com/gelozr/go-dash/internal/user"
 )
 
 func init() {
@@ -31,6 +34,15 @@ var (
 	reqLocaleCtxKey = ctxKey("req_locale")
 	loggerCtxKey    = ctxKey("logger_key")
 	userIDCtxKey    = ctxKey("user_id")
+	reqTZCtxKey     = ctxKey("req_timezone")
+	userCtxKey      = ctxKey("user")
+)
+
+const (
+	// TimezoneHeader selects the IANA timezone dates are read and bucketed in.
+	TimezoneHeader = "X-Timezone"
+	// TenantHeader selects the tenant of users belonging to several.
+	TenantHeader = "X-Tenant-ID"
 )
 
 func AuthMiddleware(a himoauth.Auth, guardName string) fiber.Handler {
@@ -44,7 +56,7 @@ func AuthMiddleware(a himoauth.Auth, guardName string) fiber.Handler {
 
 		switch guardName {
 		case "jwt":
-			token := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+			token := bearerToken(c)
 			if token == "" {
 				return fiber.NewError(http.StatusUnauthorized, "missing authorization header")
 			}
@@ -56,6 +68,8 @@ func AuthMiddleware(a himoauth.Auth, guardName string) fiber.Handler {
 					return fiber.NewError(http.StatusUnauthorized, "invalid token")
 				case errors.Is(err, auth.ErrJWTExpired):
 					return fiber.NewError(http.StatusUnauthorized, "expired token")
+				case errors.Is(err, auth.ErrJWTRevoked):
+					return fiber.NewError(http.StatusUnauthorized, "revoked token")
 				default:
 					return fmt.Errorf("parse token: %w", err)
 				}
@@ -66,12 +80,79 @@ func AuthMiddleware(a himoauth.Auth, guardName string) fiber.Handler {
 		}
 
 		ctx := himoauth.WithUserCtx(c.Context(), verified)
+		if u, ok := verified.User.(user.User); ok {
+			ctx = context.WithValue(ctx, userIDCtxKey, u.ID)
+			ctx = context.WithValue(ctx, userCtxKey, u)
+
+			// the user's preference applies unless the request chose a zone
+			if u.Timezone != "" && c.Get(TimezoneHeader) == "" {
+				if loc, err := time.LoadLocation(u.Timezone); err == nil {
+					ctx = context.WithValue(ctx, reqTZCtxKey, loc)
+				}
+			}
+		}
 
 		c.SetContext(ctx)
 		return c.Next()
 	}
 }
 
+// QueryToken accepts the bearer token as ?access_token=, and the tenant as
+// ?tenant_id=, for clients that cannot set headers, such as the browser's
+// EventSource. Only use it on routes that need it, tokens in URLs end up in
+// access logs.
+func QueryToken() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request().Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		if c.Get(TenantHeader) == "" {
+			if id := c.Query("tenant_id"); id != "" {
+				c.Request().Header.Set(TenantHeader, id)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireTenant scopes the request to the tenant chosen with the
+// X-Tenant-ID header, or the user's only tenant. It must run after
+// AuthMiddleware.
+func RequireTenant(svc *tenant.Service) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		userID, ok := UserIDFromCtx(c.Context())
+		if !ok {
+			return fiber.NewError(http.StatusUnauthorized, "unauthorized.")
+		}
+
+		var requested uuid.UUID
+		if v := c.Get(TenantHeader); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return fiber.NewError(http.StatusBadRequest, "invalid "+TenantHeader+" header.")
+			}
+			requested = id
+		}
+
+		id, err := svc.Resolve(c.Context(), userID, requested)
+		if err != nil {
+			switch {
+			case errors.Is(err, tenant.ErrTenantRequired):
+				return fiber.NewError(http.StatusBadRequest, TenantHeader+" header required.")
+			case errors.Is(err, tenant.ErrNotMember):
+				return fiber.NewError(http.StatusForbidden, "not a member of the tenant.")
+			default:
+				return fmt.Errorf("resolve tenant: %w", err)
+			}
+		}
+
+		c.SetContext(tenant.WithID(c.Context(), id))
+		return c.Next()
+	}
+}
+
 // ValidationResponse maps the validation errors into a JSON response
 func ValidationResponse() fiber.Handler {
 	return func(c fiber.Ctx) error {
@@ -140,6 +221,47 @@ func RequestLocale() fiber.Handler {
 	}
 }
 
+// RequirePermission rejects users lacking any of the permissions. It must
+// run after AuthMiddleware.
+func RequirePermission(perms ...rbac.Permission) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		u, ok := UserFromCtx(c.Context())
+		if !ok {
+			return fiber.NewError(http.StatusUnauthorized, "unauthorized.")
+		}
+
+		for _, p := range perms {
+			if !u.Can(p) {
+				return fiber.NewError(http.StatusForbidden, "missing permission "+string(p)+".")
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// RequestTimezone reads the X-Timezone header, defaulting to def. An
+// authenticated user's preference takes over from def in AuthMiddleware.
+func RequestTimezone(def *time.Location) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		loc := def
+
+		if v := c.Get(TimezoneHeader); v != "" {
+			l, err := time.LoadLocation(v)
+			// "Local" would be the server's zone, which is what this replaces
+			if err != nil || v == "Local" {
+				return fiber.NewError(http.StatusUnprocessableEntity, "invalid "+TimezoneHeader+" header.")
+			}
+			loc = l
+		}
+
+		ctx := context.WithValue(c.Context(), reqTZCtxKey, loc)
+		c.SetContext(ctx)
+
+		return c.Next()
+	}
+}
+
 func rateLimiter(max ...int) fiber.Handler {
 	var m int
 	if len(max) > 0 {
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	himoauth "github.com/gelozr/himo/auth2"
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/tenant"
+)
+
+// Policy declares who may call a route: anyone, or callers authenticated
+// by a guard, optionally holding permissions.
+type Policy struct {
+	guard       string
+	permissions []rbac.Permission
+	tenant      bool
+	declared    bool
+}
+
+// Public routes are open to anyone.
+var Public = Policy{declared: true}
+
+// Authenticated routes require a valid credential for the named guard.
+func Authenticated(guard string) Policy {
+	return Policy{guard: guard, declared: true}
+}
+
+// InTenant returns the policy additionally scoping requests to a tenant of
+// the user (see RequireTenant).
+func (p Policy) InTenant() Policy {
+	p.tenant = true
+	return p
+}
+
+// Require returns the policy additionally requiring the permissions.
+func (p Policy) Require(perms ...rbac.Permission) Policy {
+	p.permissions = append(slices.Clone(p.permissions), perms...)
+	return p
+}
+
+// PolicyRouter registers routes together with their policy, enforcing it
+// with AuthMiddleware, RequireTenant and RequirePermission. Routes inherit
+// the policy of their group; Verify fails for any route of the app that was
+// registered without one.
+type PolicyRouter struct {
+	router  fiber.Router
+	prefix  string
+	policy  Policy
+	auth    himoauth.Auth
+	tenants *tenant.Service
+
+	// shared by all routers derived from the same root
+	declared map[string]Policy
+	errs     *[]error
+}
+
+func NewPolicyRouter(r fiber.Router, prefix string, a himoauth.Auth, tenants *tenant.Service) *PolicyRouter {
+	return &PolicyRouter{
+		router:   r.Group(prefix),
+		prefix:   prefix,
+		auth:     a,
+		tenants:  tenants,
+		declared: make(map[string]Policy),
+		errs:     new([]error),
+	}
+}
+
+// Group creates a group whose routes default to p. The handlers run for
+// every route of the group, before authentication.
+func (r *PolicyRouter) Group(prefix string, p Policy, handlers ...fiber.Handler) *PolicyRouter {
+	g := *r
+	g.router = r.router.Group(prefix, handlers...)
+	g.prefix = r.prefix + prefix
+	g.policy = p
+	return &g
+}
+
+// With returns a router that registers routes under p instead of the
+// group's policy.
+func (r *PolicyRouter) With(p Policy) *PolicyRouter {
+	g := *r
+	g.policy = p
+	return &g
+}
+
+func (r *PolicyRouter) Get(path string, handler fiber.Handler, middleware ...fiber.Handler) {
+	r.add(fiber.MethodGet, path, handler, middleware)
+}
+
+func (r *PolicyRouter) Post(path string, handler fiber.Handler, middleware ...fiber.Handler) {
+	r.add(fiber.MethodPost, path, handler, middleware)
+}
+
+func (r *PolicyRouter) Put(path string, handler fiber.Handler, middleware ...fiber.Handler) {
+	r.add(fiber.MethodPut, path, handler, middleware)
+}
+
+func (r *PolicyRouter) Patch(path string, handler fiber.Handler, middleware ...fiber.Handler) {
+	r.add(fiber.MethodPatch, path, handler, middleware)
+}
+
+func (r *PolicyRouter) Delete(path string, handler fiber.Handler, middleware ...fiber.Handler) {
+	r.add(fiber.MethodDelete, path, handler, middleware)
+}
+
+// Declare records the policy of a route registered outside the router,
+// such as static files mounted on the app.
+func (r *PolicyRouter) Declare(method, path string, p Policy) {
+	r.declare(method, path, p)
+}
+
+func (r *PolicyRouter) add(method, path string, handler fiber.Handler, middleware []fiber.Handler) {
+	if !r.declare(method, r.prefix+path, r.policy) {
+		return
+	}
+
+	// authentication runs after the route's own middleware, which may have
+	// to prepare the credential (see QueryToken)
+	if r.policy.guard != "" {
+		middleware = append(slices.Clone(middleware), AuthMiddleware(r.auth, r.policy.guard))
+	}
+	if r.policy.tenant {
+		middleware = append(middleware, RequireTenant(r.tenants))
+	}
+	if len(r.policy.permissions) > 0 {
+		middleware = append(middleware, RequirePermission(r.policy.permissions...))
+	}
+
+	r.router.Add([]string{method}, path, handler, middleware...)
+}
+
+func (r *PolicyRouter) declare(method, path string, p Policy) bool {
+	if !p.declared {
+		*r.errs = append(*r.errs, fmt.Errorf("%s %s: no policy declared", method, path))
+		return false
+	}
+	if p.guard == "" && (len(p.permissions) > 0 || p.tenant) {
+		*r.errs = append(*r.errs, fmt.Errorf("%s %s: public route cannot require permissions or a tenant", method, path))
+		return false
+	}
+
+	r.declared[method+" "+path] = p
+	return true
+}
+
+// Verify fails when routes were registered without a policy, through this
+// router or directly on the app.
+func (r *PolicyRouter) Verify(app *fiber.App) error {
+	errs := slices.Clone(*r.errs)
+
+	for _, rt := range app.GetRoutes(true) {
+		if rt.Method == fiber.MethodHead {
+			// added along with GET
+			continue
+		}
+		if _, ok := r.declared[rt.Method+" "+rt.Path]; !ok {
+			errs = append(errs, fmt.Errorf("%s %s: no policy declared", rt.Method, rt.Path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -8,3 +8,34 @@ type Login struct {
 type Refresh struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
+
+type Register struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type AcceptInvitation struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type LoginTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is a TOTP or recovery code.
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
@@ -2,18 +2,29 @@ package request
 
 import (
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/customfield"
 )
 
 type CreateCustomer struct {
-	Name     string  `json:"name" validate:"required"`
-	Email    string  `json:"email" validate:"required,email"`
-	ImageURL *string `json:"image_url"`
+	Name         string             `json:"name" validate:"required"`
+	Email        string             `json:"email" validate:"required,email"`
+	ImageURL     *string            `json:"image_url"`
+	CustomFields customfield.Values `json:"custom_fields"`
 }
 
 func (req *CreateCustomer) ToCustomer() customer.Customer {
 	return customer.Customer{
-		Name:     req.Name,
-		Email:    req.Email,
-		ImageURL: req.ImageURL,
+		Name:         req.Name,
+		Email:        req.Email,
+		ImageURL:     req.ImageURL,
+		CustomFields: req.CustomFields,
 	}
 }
+
+type SetCustomFields struct {
+	CustomFields customfield.Values `json:"custom_fields"`
+}
+
+type EraseCustomer struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
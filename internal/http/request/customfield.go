@@ -0,0 +1,25 @@
+package request
+
+import (
+	"github.com/gelozr/go-dash/internal/customfield"
+)
+
+type CreateCustomField struct {
+	Entity   string   `json:"entity" validate:"required,oneof=customer invoice"`
+	Key      string   `json:"key" validate:"required,max=64"`
+	Label    string   `json:"label" validate:"required,max=255"`
+	Type     string   `json:"type" validate:"required,oneof=text number boolean date enum"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options" validate:"omitempty,max=100,dive,required,max=255"`
+}
+
+func (req *CreateCustomField) ToDefinition() customfield.Definition {
+	return customfield.Definition{
+		Entity:   customfield.Entity(req.Entity),
+		Key:      req.Key,
+		Label:    req.Label,
+		Type:     customfield.Type(req.Type),
+		Required: req.Required,
+		Options:  req.Options,
+	}
+}
@@ -0,0 +1,40 @@
+package request
+
+import (
+	"github.com/gelozr/go-dash/internal/customfield"
+	"github.com/gelozr/go-dash/internal/dashboard"
+)
+
+type Widget struct {
+	ID          string              `json:"id"`
+	Type        string              `json:"type"`
+	Period      string              `json:"period"`
+	Granularity string              `json:"granularity"`
+	Metric      string              `json:"metric"`
+	Limit       int                 `json:"limit"`
+	Months      int                 `json:"months"`
+	Filters     customfield.Filters `json:"filters"`
+}
+
+// SaveLayout replaces the user's dashboard; widget options are validated
+// by the layout service.
+type SaveLayout struct {
+	Widgets []Widget `json:"widgets" validate:"required"`
+}
+
+func (req *SaveLayout) ToWidgets() []dashboard.Widget {
+	out := make([]dashboard.Widget, len(req.Widgets))
+	for i, w := range req.Widgets {
+		out[i] = dashboard.Widget{
+			ID:          w.ID,
+			Type:        dashboard.WidgetType(w.Type),
+			Period:      dashboard.Period(w.Period),
+			Granularity: dashboard.Granularity(w.Granularity),
+			Metric:      dashboard.Metric(w.Metric),
+			Limit:       w.Limit,
+			Months:      w.Months,
+			Filters:     w.Filters,
+		}
+	}
+	return out
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/http/response"
 	"github.com/gelozr/go-dash/internal/invoice"
 	"github.com/gelozr/go-dash/internal/optional"
@@ -16,6 +17,8 @@ type CreateInvoice struct {
 	Amount     float64 `json:"amount" validate:"required"`
 	Status     string  `json:"status" validate:"required"`
 	Date       string  `json:"date" validate:"required,rfc3339"`
+
+	CustomFields customfield.Values `json:"custom_fields"`
 }
 
 func (req *CreateInvoice) ToInvoice() (invoice.Invoice, error) {
@@ -36,6 +39,8 @@ func (req *CreateInvoice) ToInvoice() (invoice.Invoice, error) {
 		Amount:     req.Amount,
 		Status:     req.Status,
 		Date:       &date,
+
+		CustomFields: req.CustomFields,
 	}, nil
 }
 
@@ -47,6 +52,9 @@ type UpdateInvoice struct {
 	// Date Optional[nullable.Null[string]] `json:"date" validate:"omitnil,required,rfc3339"`
 	// Date       Optional[nullable.Null[string]] `json:"date" validate:"omitnil,required,rfc3339"`
 	// IsActive optional.Optional[*bool]   `json:"is_active" validate:"omitnil,boolean"`
+
+	// replaces all custom field values when present
+	CustomFields optional.Optional[customfield.Values] `json:"custom_fields"`
 }
 
 func (req *UpdateInvoice) ToDTO() (invoice.UpdateInput, error) {
@@ -67,5 +75,7 @@ func (req *UpdateInvoice) ToDTO() (invoice.UpdateInput, error) {
 		Amount:     req.Amount,
 		Status:     req.Status,
 		Date:       date,
+
+		CustomFields: req.CustomFields,
 	}, nil
 }
@@ -0,0 +1,6 @@
+package request
+
+type SetUserRoles struct {
+	// Roles replaces all roles of the user; empty revokes them.
+	Roles []string `json:"roles" validate:"required,max=16,dive,required,max=64"`
+}
@@ -0,0 +1,35 @@
+package request
+
+import (
+	"github.com/gelozr/go-dash/internal/optional"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type SetTimezone struct {
+	// Timezone is an IANA name such as "Europe/Berlin"; empty resets it.
+	Timezone string `json:"timezone"`
+}
+
+type CreateUser struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type UpdateUser struct {
+	Name     optional.Optional[string] `json:"name" validate:"omitnil,required,max=255"`
+	Email    optional.Optional[string] `json:"email" validate:"omitnil,required,email,max=255"`
+	Password optional.Optional[string] `json:"password" validate:"omitnil,required,min=8,max=72"`
+}
+
+func (req *UpdateUser) ToDTO() user.UpdateInput {
+	return user.UpdateInput{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+	}
+}
+
+type InviteUser struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
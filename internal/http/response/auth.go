@@ -1,6 +1,14 @@
 package response
 
 import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
 	"github.com/gelozr/go-dash/internal/auth"
 )
 
@@ -17,3 +25,90 @@ func ToAccessToken(token auth.AccessToken) AccessToken {
 		ExpiresIn:    token.ExpiresIn,
 	}
 }
+
+type Session struct {
+	// ID is stable across refreshes of the session.
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ToSessions maps active refresh sessions, flagging the one with the
+// current refresh session id.
+func ToSessions(sessions []auth.RefreshSession, current uuid.UUID) []Session {
+	return ToList(sessions, func(r auth.RefreshSession) Session {
+		return Session{
+			ID:         r.Family(),
+			UserAgent:  r.UserAgent,
+			IP:         r.IP,
+			SignedInAt: r.SignedInAt,
+			LastUsedAt: r.LastUsedAt,
+			ExpiresAt:  r.ExpiresAt,
+			Current:    r.ID == current,
+		}
+	})
+}
+
+// JWK is a public signing key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func ToJWKS(keys []auth.SigningKey) JWKS {
+	enc := base64.RawURLEncoding
+
+	return JWKS{Keys: ToList(keys, func(k auth.SigningKey) JWK {
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Alg}
+
+		switch pub := k.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = enc.EncodeToString(pub.N.Bytes())
+			jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = enc.EncodeToString(pub)
+		}
+
+		return jwk
+	})}
+}
+
+// TwoFactorChallenge answers a login with the right password of a user
+// with two-factor authentication; the token and a code complete it.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
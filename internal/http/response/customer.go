@@ -1,24 +1,35 @@
 package response
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/customfield"
 )
 
 type Customer struct {
-	ID       uuid.UUID `json:"id"`
-	Name     string    `json:"name"`
-	Email    string    `json:"email"`
-	ImageURL *string   `json:"image_url"`
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	ImageURL   *string    `json:"image_url"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at"`
+
+	CustomFields customfield.Values `json:"custom_fields"`
 }
 
 func ToCustomer(customer customer.Customer) Customer {
 	return Customer{
-		ID:       customer.ID,
-		Name:     customer.Name,
-		Email:    customer.Email,
-		ImageURL: customer.ImageURL,
+		ID:         customer.ID,
+		Name:       customer.Name,
+		Email:      customer.Email,
+		ImageURL:   customer.ImageURL,
+		Verified:   customer.IsVerified(),
+		VerifiedAt: customer.VerifiedAt,
+
+		CustomFields: customer.CustomFields,
 	}
 }
 
@@ -34,6 +45,8 @@ type CustomerWithInvoiceInfo struct {
 	TotalInvoices int64     `json:"total_invoices"`
 	TotalPending  float64   `json:"total_pending"`
 	TotalPaid     float64   `json:"total_paid"`
+
+	CustomFields customfield.Values `json:"custom_fields"`
 }
 
 func ToCustomerWithInvoiceInfo(c customer.WithInvoiceInfo) CustomerWithInvoiceInfo {
@@ -45,6 +58,8 @@ func ToCustomerWithInvoiceInfo(c customer.WithInvoiceInfo) CustomerWithInvoiceIn
 		TotalInvoices: c.TotalInvoices,
 		TotalPending:  c.TotalPending,
 		TotalPaid:     c.TotalPaid,
+
+		CustomFields: c.CustomFields,
 	}
 }
 
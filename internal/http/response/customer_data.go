@@ -0,0 +1,102 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/app"
+	"github.com/gelozr/go-dash/internal/customer"
+)
+
+type CustomerDataExport struct {
+	Customer        Customer                 `json:"customer"`
+	Invoices        []Invoice                `json:"invoices"`
+	Verifications   []CustomerVerification   `json:"verification_emails"`
+	StatementEmails []CustomerStatementEmail `json:"statement_emails"`
+	Erasures        []CustomerErasure        `json:"erasures"`
+	Files           []string                 `json:"files"`
+	Manifest        CustomerDataManifest     `json:"manifest"`
+	ExportedAt      time.Time                `json:"exported_at"`
+}
+
+// CustomerDataManifest lists the sections of the export and the data held
+// about the customer that it leaves out.
+type CustomerDataManifest struct {
+	Included []string               `json:"included"`
+	Omitted  []CustomerDataOmission `json:"omitted"`
+}
+
+type CustomerDataOmission struct {
+	Data   string `json:"data"`
+	Reason string `json:"reason"`
+}
+
+func ToCustomerDataExport(d *app.CustomerData) CustomerDataExport {
+	files := make([]string, len(d.Files))
+	for i, f := range d.Files {
+		files[i] = f.Name
+	}
+
+	return CustomerDataExport{
+		Customer:        ToCustomer(d.Customer),
+		Invoices:        ToList(d.Invoices, ToInvoice),
+		Verifications:   ToList(d.Verifications, ToCustomerVerification),
+		StatementEmails: ToList(d.StatementEmails, ToCustomerStatementEmail),
+		Erasures:        ToList(d.Erasures, ToCustomerErasure),
+		Files:           files,
+		Manifest: CustomerDataManifest{
+			Included: []string{"customer", "invoices", "verification_emails", "statement_emails", "erasures", "files"},
+			Omitted: ToList(d.Omitted, func(o app.ExportOmission) CustomerDataOmission {
+				return CustomerDataOmission{Data: o.Data, Reason: o.Reason}
+			}),
+		},
+		ExportedAt: d.ExportedAt,
+	}
+}
+
+type CustomerVerification struct {
+	SentAt    time.Time  `json:"sent_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func ToCustomerVerification(v customer.Verification) CustomerVerification {
+	return CustomerVerification{
+		SentAt:    v.CreatedAt,
+		ExpiresAt: v.ExpiresAt,
+		UsedAt:    v.UsedAt,
+	}
+}
+
+type CustomerStatementEmail struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+func ToCustomerStatementEmail(e customer.StatementEmail) CustomerStatementEmail {
+	return CustomerStatementEmail{
+		From:   e.From,
+		To:     e.To,
+		SentAt: e.CreatedAt,
+	}
+}
+
+type CustomerErasure struct {
+	ID          uuid.UUID `json:"id"`
+	CustomerID  uuid.UUID `json:"customer_id"`
+	RequestedBy uuid.UUID `json:"requested_by"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ToCustomerErasure(e customer.Erasure) CustomerErasure {
+	return CustomerErasure{
+		ID:          e.ID,
+		CustomerID:  e.CustomerID,
+		RequestedBy: e.RequestedBy,
+		Reason:      e.Reason,
+		CreatedAt:   e.CreatedAt,
+	}
+}
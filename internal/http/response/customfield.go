@@ -0,0 +1,33 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customfield"
+)
+
+type CustomFieldDefinition struct {
+	ID        uuid.UUID `json:"id"`
+	Entity    string    `json:"entity"`
+	Key       string    `json:"key"`
+	Label     string    `json:"label"`
+	Type      string    `json:"type"`
+	Required  bool      `json:"required"`
+	Options   []string  `json:"options"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToCustomFieldDefinition(d customfield.Definition) CustomFieldDefinition {
+	return CustomFieldDefinition{
+		ID:        d.ID,
+		Entity:    string(d.Entity),
+		Key:       d.Key,
+		Label:     d.Label,
+		Type:      string(d.Type),
+		Required:  d.Required,
+		Options:   d.Options,
+		CreatedAt: d.CreatedAt,
+	}
+}
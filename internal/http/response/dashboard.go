@@ -1,10 +1,16 @@
 package response
 
-import "github.com/gelozr/go-dash/internal/dashboard"
+import (
+	"time"
+
+	"github.com/gelozr/go-dash/internal/dashboard"
+)
 
 type Overview struct {
-	InvoiceCount  int64 `json:"invoice_count"`
-	CustomerCount int64 `json:"customer_count"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	InvoiceCount  int64      `json:"invoice_count"`
+	CustomerCount int64      `json:"customer_count"`
 	InvoiceStatus struct {
 		Paid    float64 `json:"paid"`
 		Pending float64 `json:"pending"`
@@ -13,6 +19,12 @@ type Overview struct {
 
 func ToOverview(o *dashboard.Overview) Overview {
 	var r Overview
+	if !o.Range.From.IsZero() {
+		r.From = &o.Range.From
+	}
+	if !o.Range.To.IsZero() {
+		r.To = &o.Range.To
+	}
 	r.CustomerCount = o.CustomerCount
 	r.InvoiceCount = o.InvoiceCount
 	r.InvoiceStatus.Paid = o.InvoiceStatus.Paid
@@ -21,18 +33,245 @@ func ToOverview(o *dashboard.Overview) Overview {
 	return r
 }
 
-type MonthlyRevenue struct {
-	Month  string  `json:"month"`
-	Amount float64 `json:"amount"`
+type Delta struct {
+	Absolute float64  `json:"absolute"`
+	Percent  *float64 `json:"percent"`
+}
+
+type OverviewDeltas struct {
+	InvoiceCount  Delta `json:"invoice_count"`
+	CustomerCount Delta `json:"customer_count"`
+	Paid          Delta `json:"paid"`
+	Pending       Delta `json:"pending"`
+}
+
+// OverviewComparison keeps the current period's fields at the top level, so
+// clients reading the plain overview keep working.
+type OverviewComparison struct {
+	Overview
+	Period   string          `json:"period"`
+	Previous *Overview       `json:"previous,omitempty"`
+	Deltas   *OverviewDeltas `json:"deltas,omitempty"`
+}
+
+func ToOverviewComparison(period string, c *dashboard.OverviewComparison) OverviewComparison {
+	r := OverviewComparison{
+		Overview: ToOverview(&c.Current),
+		Period:   period,
+	}
+
+	if c.Previous != nil {
+		prev := ToOverview(c.Previous)
+		r.Previous = &prev
+	}
+
+	if d := c.Deltas; d != nil {
+		toDelta := func(d dashboard.Delta) Delta {
+			return Delta{Absolute: d.Absolute, Percent: d.Percent}
+		}
+		r.Deltas = &OverviewDeltas{
+			InvoiceCount:  toDelta(d.InvoiceCount),
+			CustomerCount: toDelta(d.CustomerCount),
+			Paid:          toDelta(d.Paid),
+			Pending:       toDelta(d.Pending),
+		}
+	}
+
+	return r
+}
+
+type Revenue struct {
+	Period string    `json:"period"`
+	Start  time.Time `json:"start"`
+	Amount float64   `json:"amount"`
+}
+
+func ToRevenueList(data []dashboard.Revenue, g dashboard.Granularity) []Revenue {
+	return ToList(data, func(r dashboard.Revenue) Revenue {
+		return Revenue{
+			Period: g.Label(r.Period),
+			Start:  r.Period,
+			Amount: r.Amount,
+		}
+	})
+}
+
+// KPIs documents each indicator's definition; ratios are null when their
+// denominator is zero.
+type KPIs struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"` // exclusive
+
+	// invoices dated within the range
+	InvoiceCount   int64   `json:"invoice_count"`
+	InvoicedAmount float64 `json:"invoiced_amount"`
+	PaidAmount     float64 `json:"paid_amount"`
+
+	// amount invoiced before "to" and not yet paid by then
+	Receivables float64 `json:"receivables"`
+	// days sales outstanding: receivables / invoiced_amount * days in range
+	DSO *float64 `json:"dso"`
+	// paid_amount / invoiced_amount, in percent
+	CollectionRate *float64 `json:"collection_rate"`
+	// invoiced_amount / invoice_count
+	AverageInvoiceValue *float64 `json:"average_invoice_value"`
+	// median days from invoice date to payment, over invoices paid within the range
+	MedianDaysToPay *float64 `json:"median_days_to_pay"`
+	// average monthly amount invoiced over the 3 full months before "to",
+	// to customers invoiced in each of those months
+	MRR float64 `json:"mrr"`
 }
 
-func ToMonthlyRevenue(r dashboard.MonthlyRevenue) MonthlyRevenue {
-	return MonthlyRevenue{
-		Month:  r.Month,
-		Amount: r.Amount,
+func ToKPIs(k *dashboard.KPIs) KPIs {
+	return KPIs{
+		From:                k.Range.From,
+		To:                  k.Range.To,
+		InvoiceCount:        k.Totals.Count,
+		InvoicedAmount:      k.Totals.Amount,
+		PaidAmount:          k.Totals.PaidAmount,
+		Receivables:         k.Receivables,
+		DSO:                 k.DSO,
+		CollectionRate:      k.CollectionRate,
+		AverageInvoiceValue: k.AverageInvoiceValue,
+		MedianDaysToPay:     k.MedianDaysToPay,
+		MRR:                 k.MRR,
 	}
 }
 
-func ToMonthlyRevenueList(data []dashboard.MonthlyRevenue) []MonthlyRevenue {
-	return ToList(data, ToMonthlyRevenue)
+type Concentration struct {
+	TopN      int     `json:"top_n"`
+	TopPaid   float64 `json:"top_paid"`
+	TotalPaid float64 `json:"total_paid"`
+	// share of paid revenue from the top_n customers by paid amount, in percent
+	Share *float64 `json:"share"`
+}
+
+type TopCustomers struct {
+	Metric        string                    `json:"metric"`
+	From          *time.Time                `json:"from,omitempty"`
+	To            *time.Time                `json:"to,omitempty"`
+	Customers     []CustomerWithInvoiceInfo `json:"customers"`
+	Concentration Concentration             `json:"concentration"`
+}
+
+func ToTopCustomers(metric dashboard.Metric, r dashboard.Range, t *dashboard.TopCustomers) TopCustomers {
+	out := TopCustomers{
+		Metric:    string(metric),
+		Customers: ToCustomerWithInvoiceInfoList(t.Customers),
+		Concentration: Concentration{
+			TopN:      t.Concentration.TopN,
+			TopPaid:   t.Concentration.TopPaid,
+			TotalPaid: t.Concentration.TotalPaid,
+			Share:     t.Concentration.Share,
+		},
+	}
+	if !r.From.IsZero() {
+		out.From = &r.From
+	}
+	if !r.To.IsZero() {
+		out.To = &r.To
+	}
+
+	return out
+}
+
+type ForecastPoint struct {
+	Period string    `json:"period"`
+	Start  time.Time `json:"start"`
+	// projected amount invoiced in the month
+	Billed float64 `json:"billed"`
+	// expected collection of currently open invoices in the month
+	Receivables float64 `json:"receivables"`
+	// projected cash collected: receivables plus collected projected billing
+	Expected float64 `json:"expected"`
+	Lower80  float64 `json:"lower_80"`
+	Upper80  float64 `json:"upper_80"`
+	Lower95  float64 `json:"lower_95"`
+	Upper95  float64 `json:"upper_95"`
+}
+
+// ForecastModel lists the inputs of the model, described in the dashboard package.
+type ForecastModel struct {
+	Name string `json:"name"`
+	// mean billing of the last full months
+	Level float64 `json:"level"`
+	// standard deviation of the one-step billing errors
+	Sigma float64 `json:"sigma"`
+	// recurring revenue used as the billing floor
+	MRR float64 `json:"mrr"`
+	// share of billing expected to be collected, 0-1
+	CollectionRate  float64 `json:"collection_rate"`
+	MedianDaysToPay float64 `json:"median_days_to_pay"`
+	// months between billing and collection
+	PaymentLagMonths int `json:"payment_lag_months"`
+	HistoryMonths    int `json:"history_months"`
+}
+
+type Forecast struct {
+	Model  ForecastModel   `json:"model"`
+	Points []ForecastPoint `json:"points"`
+}
+
+func ToForecast(f *dashboard.Forecast) Forecast {
+	return Forecast{
+		Model: ForecastModel{
+			Name:             "seasonal_moving_average",
+			Level:            f.Level,
+			Sigma:            f.Sigma,
+			MRR:              f.MRR,
+			CollectionRate:   f.CollectionRate,
+			MedianDaysToPay:  f.MedianDaysToPay,
+			PaymentLagMonths: f.PaymentLag,
+			HistoryMonths:    f.HistoryMonths,
+		},
+		Points: ToList(f.Points, func(p dashboard.ForecastPoint) ForecastPoint {
+			return ForecastPoint{
+				Period:      dashboard.GranularityMonth.Label(p.Period),
+				Start:       p.Period,
+				Billed:      p.Billed,
+				Receivables: p.Receivables,
+				Expected:    p.Expected,
+				Lower80:     p.Lower80,
+				Upper80:     p.Upper80,
+				Lower95:     p.Lower95,
+				Upper95:     p.Upper95,
+			}
+		}),
+	}
+}
+
+type CohortPeriod struct {
+	// months since the cohort's first invoice month
+	Offset int `json:"offset"`
+	// customers of the cohort invoiced in the month
+	Active int `json:"active"`
+	// active / size, in percent
+	Retention float64 `json:"retention"`
+	// amount invoiced to the cohort in the month
+	Revenue float64 `json:"revenue"`
+}
+
+type Cohort struct {
+	Cohort  string         `json:"cohort"`
+	Start   time.Time      `json:"start"`
+	Size    int            `json:"size"`
+	Periods []CohortPeriod `json:"periods"`
+}
+
+func ToCohortList(data []dashboard.Cohort) []Cohort {
+	return ToList(data, func(c dashboard.Cohort) Cohort {
+		return Cohort{
+			Cohort: dashboard.GranularityMonth.Label(c.Month),
+			Start:  c.Month,
+			Size:   c.Size,
+			Periods: ToList(c.Periods, func(p dashboard.CohortPeriod) CohortPeriod {
+				return CohortPeriod{
+					Offset:    p.Offset,
+					Active:    p.Active,
+					Retention: p.Retention,
+					Revenue:   p.Revenue,
+				}
+			}),
+		}
+	})
 }
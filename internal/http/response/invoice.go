@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/invoice"
 )
 
@@ -14,7 +15,10 @@ type Invoice struct {
 	Amount     float64    `json:"amount"`
 	Status     string     `json:"status"`
 	Date       *time.Time `json:"date"`
+	PaidAt     *time.Time `json:"paid_at"`
 	IsActive   *bool      `json:"is_active"`
+
+	CustomFields customfield.Values `json:"custom_fields"`
 }
 
 func ToInvoice(inv invoice.Invoice) Invoice {
@@ -24,7 +28,10 @@ func ToInvoice(inv invoice.Invoice) Invoice {
 		Amount:     inv.Amount,
 		Status:     inv.Status,
 		Date:       inv.Date,
+		PaidAt:     inv.PaidAt,
 		IsActive:   inv.IsActive,
+
+		CustomFields: inv.CustomFields,
 	}
 }
 
@@ -0,0 +1,51 @@
+package response
+
+import (
+	"time"
+
+	"github.com/gelozr/go-dash/internal/customfield"
+	"github.com/gelozr/go-dash/internal/dashboard"
+)
+
+type Widget struct {
+	ID          string              `json:"id"`
+	Type        string              `json:"type"`
+	Period      string              `json:"period,omitempty"`
+	Granularity string              `json:"granularity,omitempty"`
+	Metric      string              `json:"metric,omitempty"`
+	Limit       int                 `json:"limit,omitempty"`
+	Months      int                 `json:"months,omitempty"`
+	Filters     customfield.Filters `json:"filters,omitempty"`
+}
+
+type Layout struct {
+	Widgets []Widget `json:"widgets"`
+	// IsDefault is set when the user has not saved a layout yet.
+	IsDefault bool       `json:"is_default"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func ToLayout(l *dashboard.Layout) Layout {
+	r := Layout{
+		Widgets:   make([]Widget, len(l.Widgets)),
+		IsDefault: l.UpdatedAt.IsZero(),
+	}
+	if !l.UpdatedAt.IsZero() {
+		r.UpdatedAt = &l.UpdatedAt
+	}
+
+	for i, w := range l.Widgets {
+		r.Widgets[i] = Widget{
+			ID:          w.ID,
+			Type:        string(w.Type),
+			Period:      string(w.Period),
+			Granularity: string(w.Granularity),
+			Metric:      string(w.Metric),
+			Limit:       w.Limit,
+			Months:      w.Months,
+			Filters:     w.Filters,
+		}
+	}
+
+	return r
+}
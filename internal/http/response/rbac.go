@@ -0,0 +1,30 @@
+package response
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/rbac"
+)
+
+type Role struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	Permissions []rbac.Permission `json:"permissions"`
+}
+
+func ToRole(r rbac.Role) Role {
+	perms := r.Permissions
+	if perms == nil {
+		perms = []rbac.Permission{}
+	}
+
+	return Role{
+		ID:          r.ID,
+		Name:        r.Name,
+		Permissions: perms,
+	}
+}
+
+func ToRoles(roles []rbac.Role) []Role {
+	return ToList(roles, ToRole)
+}
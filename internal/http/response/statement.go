@@ -0,0 +1,57 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/statement"
+)
+
+type Statement struct {
+	Customer       Customer        `json:"customer"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	OpeningBalance float64         `json:"opening_balance"`
+	Lines          []StatementLine `json:"lines"`
+	TotalDebit     float64         `json:"total_debit"`
+	TotalCredit    float64         `json:"total_credit"`
+	ClosingBalance float64         `json:"closing_balance"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+type StatementLine struct {
+	Date        time.Time `json:"date"`
+	Kind        string    `json:"kind"`
+	Reference   uuid.UUID `json:"reference"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"`
+}
+
+func ToStatement(s statement.Statement) Statement {
+	return Statement{
+		Customer:       ToCustomer(s.Customer),
+		From:           s.From.Format(time.DateOnly),
+		To:             s.To.Format(time.DateOnly),
+		OpeningBalance: s.OpeningBalance,
+		Lines:          ToList(s.Lines, ToStatementLine),
+		TotalDebit:     s.TotalDebit,
+		TotalCredit:    s.TotalCredit,
+		ClosingBalance: s.ClosingBalance,
+		GeneratedAt:    s.GeneratedAt,
+	}
+}
+
+func ToStatementLine(l statement.Line) StatementLine {
+	return StatementLine{
+		Date:        l.Date,
+		Kind:        string(l.Kind),
+		Reference:   l.Reference,
+		Description: l.Description,
+		Debit:       l.Debit,
+		Credit:      l.Credit,
+		Balance:     l.Balance,
+	}
+}
@@ -0,0 +1,39 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type User struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Timezone string    `json:"timezone"`
+}
+
+func ToUser(u user.User) User {
+	return User{
+		ID:       u.ID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Timezone: u.Timezone,
+	}
+}
+
+type Invitation struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func ToInvitation(inv user.Invitation) Invitation {
+	return Invitation{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		ExpiresAt: inv.ExpiresAt,
+	}
+}
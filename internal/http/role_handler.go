@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type RoleHandler struct {
+	svc       *rbac.Service
+	userSvc   *user.Service
+	validator validation.Validator
+	logger    logger.Logger
+}
+
+func NewRoleHandler(svc *rbac.Service, userSvc *user.Service, validator validation.Validator, log logger.Logger) *RoleHandler {
+	return &RoleHandler{
+		svc:       svc,
+		userSvc:   userSvc,
+		validator: validator,
+		logger:    log.With("component", "http.role"),
+	}
+}
+
+func (h *RoleHandler) List(c fiber.Ctx) error {
+	roles, err := h.svc.ListRoles(c.Context())
+	if err != nil {
+		return fmt.Errorf("list roles: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToRoles(roles)),
+	)
+}
+
+func (h *RoleHandler) GetUserRoles(c fiber.Ctx) error {
+	id, err := h.userID(c)
+	if err != nil {
+		return err
+	}
+
+	roles, err := h.svc.UserRoles(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get user roles: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToRoles(roles)),
+	)
+}
+
+func (h *RoleHandler) SetUserRoles(c fiber.Ctx) error {
+	id, err := h.userID(c)
+	if err != nil {
+		return err
+	}
+
+	var req request.SetUserRoles
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("set user roles bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("set user roles validation: %w", err)
+	}
+
+	roles, err := h.svc.SetUserRoles(c.Context(), id, req.Roles)
+	if err != nil {
+		switch {
+		case errors.Is(err, rbac.ErrRoleNotFound):
+			return validation.Errors{"roles": {"roles must name existing roles"}}
+		case errors.Is(err, rbac.ErrLastAdmin):
+			return fiber.NewError(fiber.StatusConflict, "cannot remove the last admin.")
+		default:
+			return fmt.Errorf("set user roles: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToRoles(roles)),
+	)
+}
+
+// userID parses the :id param of an existing user.
+func (h *RoleHandler) userID(c fiber.Ctx) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	if _, err := h.userSvc.Get(c.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return uuid.Nil, fiber.NewError(fiber.StatusNotFound, "User not found.")
+		default:
+			return uuid.Nil, fmt.Errorf("get user: %w", err)
+		}
+	}
+
+	return id, nil
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/auth"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+// SessionHandler manages where users are signed in. A session is a refresh
+// token family, identified by the family id.
+type SessionHandler struct {
+	token   *auth.Token
+	jwt     *auth.JWTDriver
+	userSvc *user.Service
+	logger  logger.Logger
+}
+
+func NewSessionHandler(token *auth.Token, jwt *auth.JWTDriver, userSvc *user.Service, log logger.Logger) *SessionHandler {
+	return &SessionHandler{
+		token:   token,
+		jwt:     jwt,
+		userSvc: userSvc,
+		logger:  log.With("component", "http.session"),
+	}
+}
+
+// List serves the active sessions of the current user.
+func (h *SessionHandler) List(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	return h.list(c, userID)
+}
+
+// Revoke ends a session of the current user.
+func (h *SessionHandler) Revoke(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	return h.revoke(c, userID, c.Params("id"))
+}
+
+// ListForUser serves the active sessions of the :id user.
+func (h *SessionHandler) ListForUser(c fiber.Ctx) error {
+	userID, err := h.userID(c)
+	if err != nil {
+		return err
+	}
+
+	return h.list(c, userID)
+}
+
+// RevokeForUser ends the :sessionId session of the :id user.
+func (h *SessionHandler) RevokeForUser(c fiber.Ctx) error {
+	userID, err := h.userID(c)
+	if err != nil {
+		return err
+	}
+
+	return h.revoke(c, userID, c.Params("sessionId"))
+}
+
+func (h *SessionHandler) list(c fiber.Ctx, userID uuid.UUID) error {
+	sessions, err := h.token.ListSessions(c.Context(), userID)
+	if err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+
+	// the request's own session is marked, whoever's sessions are listed
+	var current uuid.UUID
+	if claims, err := h.jwt.Parse(bearerToken(c)); err == nil {
+		current = claims.SessionID
+	}
+
+	return c.JSON(
+		response.New(response.ToSessions(sessions, current)),
+	)
+}
+
+func (h *SessionHandler) revoke(c fiber.Ctx, userID uuid.UUID, param string) error {
+	id, err := uuid.Parse(param)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid session id.")
+	}
+
+	if err := h.token.RevokeSession(c.Context(), userID, id); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshSessionNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "Session not found.")
+		default:
+			return fmt.Errorf("revoke session: %w", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// userID parses the :id param of an existing user.
+func (h *SessionHandler) userID(c fiber.Ctx) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	if _, err := h.userSvc.Get(c.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return uuid.Nil, fiber.NewError(fiber.StatusNotFound, "User not found.")
+		default:
+			return uuid.Nil, fmt.Errorf("get user: %w", err)
+		}
+	}
+
+	return id, nil
+}
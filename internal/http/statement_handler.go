@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/app"
+	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/statement"
+)
+
+type StatementHandler struct {
+	stmt   *app.CustomerStatement
+	logger logger.Logger
+}
+
+func NewStatementHandler(stmt *app.CustomerStatement, log logger.Logger) *StatementHandler {
+	return &StatementHandler{
+		stmt:   stmt,
+		logger: log.With("component", "http.statement"),
+	}
+}
+
+// Get renders the statement as JSON (default), HTML or PDF depending on ?format=.
+func (h *StatementHandler) Get(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	from, to, err := statementPeriod(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "html" && format != "pdf" {
+		return validation.Errors{"format": {"format must be one of json, html, pdf"}}
+	}
+
+	s, err := h.stmt.Execute(c.Context(), id, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		default:
+			return fmt.Errorf("customer statement: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case "html":
+		if err = statement.RenderHTML(&buf, *s); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Send(buf.Bytes())
+	case "pdf":
+		if err = statement.RenderPDF(&buf, *s); err != nil {
+			return err
+		}
+		c.Attachment(fmt.Sprintf("statement-%s-%s.pdf", id, to.Format(time.DateOnly)))
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		return c.Send(buf.Bytes())
+	default:
+		return c.JSON(
+			response.New(response.ToStatement(*s)),
+		)
+	}
+}
+
+func (h *StatementHandler) Email(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	from, to, err := statementPeriod(c)
+	if err != nil {
+		return err
+	}
+
+	s, err := h.stmt.Email(c.Context(), id, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrCustomerNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "customer not found.")
+		case errors.Is(err, customer.ErrCustomerErased):
+			return fiber.NewError(fiber.StatusConflict, "customer is erased.")
+		default:
+			return fmt.Errorf("email customer statement: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToStatement(*s)),
+	)
+}
+
+// statementPeriod reads ?from= and ?to= as YYYY-MM-DD. It defaults to the
+// current month; to is inclusive of the whole day.
+func statementPeriod(c fiber.Ctx) (time.Time, time.Time, error) {
+	t := now(c)
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	to := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	from, to, err := dateRange(c, from, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return from, to.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
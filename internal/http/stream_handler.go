@@ -0,0 +1,200 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	himoauth "github.com/gelozr/himo/auth2"
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/gelozr/go-dash/internal/dashboard"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/rbac"
+	"github.com/gelozr/go-dash/internal/tenant"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+const (
+	streamHeartbeat    = 15 * time.Second
+	streamWriteTimeout = 10 * time.Second
+	streamRetry        = 3 * time.Second
+)
+
+type StreamHandler struct {
+	feed      *dashboard.Feed
+	server    *FiberServer
+	auth      himoauth.Auth
+	tenantSvc *tenant.Service
+	logger    logger.Logger
+}
+
+func NewStreamHandler(
+	feed *dashboard.Feed,
+	server *FiberServer,
+	auth himoauth.Auth,
+	tenantSvc *tenant.Service,
+	log logger.Logger,
+) *StreamHandler {
+	return &StreamHandler{
+		feed:      feed,
+		server:    server,
+		auth:      auth,
+		tenantSvc: tenantSvc,
+		logger:    log.With("component", "http.stream"),
+	}
+}
+
+// Dashboard streams live dashboard updates as Server-Sent Events: "overview"
+// with the all-time overview and its deltas, and "invoice" for new invoices.
+// A client resuming with Last-Event-ID gets the events it missed, or a fresh
+// overview when they are no longer kept. On every heartbeat the token, the
+// reports permission and the tenant membership are re-checked, and the
+// stream ends once any of them is gone.
+func (h *StreamHandler) Dashboard(c fiber.Ctx) error {
+	token := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+
+	guard, err := h.auth.Handler("jwt")
+	if err != nil {
+		return fmt.Errorf("guard: %w", err)
+	}
+
+	lastID, _ := strconv.ParseUint(c.Get("Last-Event-ID"), 10, 64)
+
+	tenantID, ok := tenant.FromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "tenant required.")
+	}
+
+	sub, cancel := h.feed.Subscribe(tenantID, lastID)
+
+	var snapshot *dashboard.Overview
+	if !sub.Complete {
+		snapshot, err = h.feed.Snapshot(c.Context())
+		if err != nil {
+			cancel()
+			return fmt.Errorf("dashboard snapshot: %w", err)
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	conn := c.RequestCtx().Conn()
+	done := h.server.Done()
+	log := h.logger
+
+	// whether the user may still read the tenant's reports
+	allowed := func() (string, bool) {
+		ctx, cancel := context.WithTimeout(context.Background(), streamWriteTimeout)
+		defer cancel()
+
+		verified, err := guard.Validate(ctx, token)
+		if err != nil {
+			return "unauthorized", false
+		}
+
+		u, ok := verified.User.(user.User)
+		if !ok || !u.Can(rbac.ReportsRead) {
+			return "forbidden", false
+		}
+
+		member, err := h.tenantSvc.IsMember(ctx, tenantID, u.ID)
+		if err != nil {
+			log.Error("stream membership check", "tenant_id", tenantID, "error", err.Error())
+			return "forbidden", false
+		}
+		if !member {
+			return "forbidden", false
+		}
+
+		return "", true
+	}
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		// the server's write timeout is per response; extend it per message instead
+		flush := func() bool {
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			return w.Flush() == nil
+		}
+
+		send := func(id uint64, event string, data any) bool {
+			b, err := json.Marshal(data)
+			if err != nil {
+				log.Error("marshal stream event", "event", event, "error", err.Error())
+				return true
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, b)
+			return flush()
+		}
+
+		sendEvent := func(e dashboard.FeedEvent) bool {
+			switch e.Type {
+			case dashboard.FeedOverview:
+				return send(e.ID, string(e.Type), response.ToOverviewComparison(string(dashboard.PeriodAll), e.Overview))
+			case dashboard.FeedInvoice:
+				return send(e.ID, string(e.Type), response.ToInvoiceWithCustomerInfo(*e.Invoice))
+			}
+			return true
+		}
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+		if snapshot != nil {
+			cmp := &dashboard.OverviewComparison{Current: *snapshot}
+			if !send(sub.LastID, string(dashboard.FeedOverview), response.ToOverviewComparison(string(dashboard.PeriodAll), cmp)) {
+				return
+			}
+		} else if !flush() {
+			return
+		}
+
+		for _, e := range sub.Replay {
+			if !sendEvent(e) {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(streamHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				fmt.Fprint(w, "event: shutdown\ndata: {}\n\n")
+				flush()
+				return
+
+			case e, ok := <-sub.Events:
+				if !ok {
+					// dropped for falling behind; the client reconnects with Last-Event-ID
+					return
+				}
+				if !sendEvent(e) {
+					return
+				}
+
+			case <-ticker.C:
+				if event, ok := allowed(); !ok {
+					fmt.Fprintf(w, "event: %s\ndata: {}\n\n", event)
+					flush()
+					return
+				}
+
+				fmt.Fprint(w, ": heartbeat\n\n")
+				if !flush() {
+					return
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/tenant"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+type TenantHandler struct {
+	svc       *tenant.Service
+	userSvc   *user.Service
+	validator validation.Validator
+	logger    logger.Logger
+}
+
+func NewTenantHandler(svc *tenant.Service, userSvc *user.Service, validator validation.Validator, log logger.Logger) *TenantHandler {
+	return &TenantHandler{
+		svc:       svc,
+		userSvc:   userSvc,
+		validator: validator,
+		logger:    log.With("component", "http.tenant"),
+	}
+}
+
+// List serves the tenants of the current user.
+func (h *TenantHandler) List(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	tenants, err := h.svc.ListForUser(c.Context(), userID)
+	if err != nil {
+		return fmt.Errorf("list tenants: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.ToTenants(tenants)),
+	)
+}
+
+// Create creates a tenant with the current user as its first member.
+func (h *TenantHandler) Create(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	var req request.CreateTenant
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("create tenant bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("create tenant validation: %w", err)
+	}
+
+	t, err := h.svc.Create(c.Context(), req.Name, userID)
+	if err != nil {
+		return fmt.Errorf("create tenant: %w", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		response.New(response.ToTenant(*t)),
+	)
+}
+
+func (h *TenantHandler) AddMember(c fiber.Ctx) error {
+	tenantID, userID, err := h.member(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.AddMember(c.Context(), tenantID, userID); err != nil {
+		return fmt.Errorf("add tenant member: %w", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *TenantHandler) RemoveMember(c fiber.Ctx) error {
+	tenantID, userID, err := h.member(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.RemoveMember(c.Context(), tenantID, userID); err != nil {
+		return fmt.Errorf("remove tenant member: %w", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// member parses the :id and :userId params. Members are only managed by
+// members of the tenant, so a tenant cannot be joined from outside.
+func (h *TenantHandler) member(c fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	callerID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	tenantID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid user id.")
+	}
+
+	if _, err := h.svc.Resolve(c.Context(), callerID, tenantID); err != nil {
+		switch {
+		case errors.Is(err, tenant.ErrNotMember):
+			// do not reveal whether the tenant exists
+			return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusNotFound, "Tenant not found.")
+		default:
+			return uuid.Nil, uuid.Nil, fmt.Errorf("resolve tenant: %w", err)
+		}
+	}
+
+	if _, err := h.userSvc.Get(c.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusNotFound, "User not found.")
+		default:
+			return uuid.Nil, uuid.Nil, fmt.Errorf("get user: %w", err)
+		}
+	}
+
+	return tenantID, userID, nil
+}
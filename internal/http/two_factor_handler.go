@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/user"
+)
+
+// TwoFactorHandler lets the current user turn TOTP two-factor
+// authentication on and off.
+type TwoFactorHandler struct {
+	twoFactor *user.TwoFactorAuth
+	validator validation.Validator
+	logger    logger.Logger
+}
+
+func NewTwoFactorHandler(twoFactor *user.TwoFactorAuth, validator validation.Validator, log logger.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactor: twoFactor,
+		validator: validator,
+		logger:    log.With("component", "http.two_factor"),
+	}
+}
+
+func (h *TwoFactorHandler) Status(c fiber.Ctx) error {
+	u, ok := UserFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	enabled, err := h.twoFactor.Enabled(c.Context(), u.ID)
+	if err != nil {
+		return fmt.Errorf("check two-factor: %w", err)
+	}
+
+	return c.JSON(
+		response.New(response.TwoFactorStatus{Enabled: enabled}),
+	)
+}
+
+// Enroll starts enrollment, returning the secret for the authenticator app.
+// It is not required at login until confirmed.
+func (h *TwoFactorHandler) Enroll(c fiber.Ctx) error {
+	u, ok := UserFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	secret, uri, err := h.twoFactor.Enroll(c.Context(), &u)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrTwoFactorEnabled):
+			return fiber.NewError(fiber.StatusConflict, "two-factor already enabled.")
+		default:
+			return fmt.Errorf("enroll two-factor: %w", err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		response.New(response.TwoFactorEnrollment{Secret: secret, URI: uri}),
+	)
+}
+
+// Confirm enables two-factor with a code from the authenticator app and
+// returns the recovery codes, which are not shown again.
+func (h *TwoFactorHandler) Confirm(c fiber.Ctx) error {
+	u, ok := UserFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	var req request.TwoFactorCode
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing two-factor confirm request: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("two-factor confirm request validation: %w", err)
+	}
+
+	codes, err := h.twoFactor.Confirm(c.Context(), u.ID, req.Code)
+	if err != nil {
+		return h.codeError(err, "confirm two-factor")
+	}
+
+	return c.JSON(
+		response.New(response.RecoveryCodes{RecoveryCodes: codes}),
+	)
+}
+
+// Disable turns two-factor off with a TOTP or recovery code.
+func (h *TwoFactorHandler) Disable(c fiber.Ctx) error {
+	u, ok := UserFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	var req request.TwoFactorCode
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("parsing two-factor disable request: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("two-factor disable request validation: %w", err)
+	}
+
+	if err := h.twoFactor.Disable(c.Context(), u.ID, req.Code); err != nil {
+		return h.codeError(err, "disable two-factor")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *TwoFactorHandler) codeError(err error, op string) error {
+	switch {
+	case errors.Is(err, user.ErrTwoFactorCodeInvalid):
+		return validation.Errors{"code": {"invalid code"}}
+	case errors.Is(err, user.ErrTwoFactorEnabled):
+		return fiber.NewError(fiber.StatusConflict, "two-factor already enabled.")
+	case errors.Is(err, user.ErrTwoFactorNotEnrolled):
+		return fiber.NewError(fiber.StatusConflict, "two-factor not enrolled.")
+	default:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+}
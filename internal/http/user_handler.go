@@ -7,22 +7,194 @@ import (
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/app"
+	"github.com/gelozr/go-dash/internal/http/request"
+	"github.com/gelozr/go-dash/internal/http/response"
+	"github.com/gelozr/go-dash/internal/http/validation"
+	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/rbac"
 	"github.com/gelozr/go-dash/internal/user"
 )
 
 type UserHandler struct {
-	svc    *user.Service
-	logger logger.Logger
+	svc        *user.Service
+	inviter    *user.Inviter
+	updateUser *app.UpdateUser
+	deleteUser *app.DeleteUser
+	validator  validation.Validator
+	logger     logger.Logger
 }
 
-func NewUserHandler(svc *user.Service, log logger.Logger) *UserHandler {
+func NewUserHandler(
+	svc *user.Service,
+	inviter *user.Inviter,
+	updateUser *app.UpdateUser,
+	deleteUser *app.DeleteUser,
+	validator validation.Validator,
+	log logger.Logger,
+) *UserHandler {
 	return &UserHandler{
-		svc:    svc,
-		logger: log.With("component", "http.user"),
+		svc:        svc,
+		inviter:    inviter,
+		updateUser: updateUser,
+		deleteUser: deleteUser,
+		validator:  validator,
+		logger:     log.With("component", "http.user"),
 	}
 }
 
+// List serves ?page= and ?size= (default 10, at most 100) of all users.
+func (h *UserHandler) List(c fiber.Ctx) error {
+	size := getDefaultNum(c.Query("size"), 10)
+	page := getDefaultNum(c.Query("page"), 1)
+
+	result, err := h.svc.List(c.Context(), listing.NewPage(page, size))
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+
+	return c.JSON(
+		response.PaginateList(result, response.ToUser),
+	)
+}
+
+func (h *UserHandler) Get(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	u, err := h.svc.Get(c.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "User not found.")
+		default:
+			return fmt.Errorf("get user: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToUser(*u)),
+	)
+}
+
+func (h *UserHandler) Create(c fiber.Ctx) error {
+	var req request.CreateUser
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("create user bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("create user validation: %w", err)
+	}
+
+	u, err := h.svc.Create(c.Context(), req.Name, req.Email, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrEmailAlreadyTaken):
+			return validation.Errors{"email": {"email already taken"}}
+		default:
+			return fmt.Errorf("create user: %w", err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		response.New(response.ToUser(*u)),
+	)
+}
+
+func (h *UserHandler) Update(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	var req request.UpdateUser
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("update user bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("update user validation: %w", err)
+	}
+
+	u, err := h.updateUser.Execute(c.Context(), id, req.ToDTO())
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "User not found.")
+		case errors.Is(err, user.ErrEmailAlreadyTaken):
+			return validation.Errors{"email": {"email already taken"}}
+		default:
+			return fmt.Errorf("update user: %w", err)
+		}
+	}
+
+	return c.JSON(
+		response.New(response.ToUser(*u)),
+	)
+}
+
+func (h *UserHandler) Delete(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid id.")
+	}
+
+	if callerID, _ := UserIDFromCtx(c.Context()); callerID == id {
+		return fiber.NewError(fiber.StatusConflict, "cannot delete yourself.")
+	}
+
+	if err := h.deleteUser.Execute(c.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "User not found.")
+		case errors.Is(err, rbac.ErrLastAdmin):
+			return fiber.NewError(fiber.StatusConflict, "cannot delete the last admin.")
+		default:
+			return fmt.Errorf("delete user: %w", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Invite emails an invitation to create an account to the address.
+func (h *UserHandler) Invite(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	var req request.InviteUser
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("invite user bind request body: %w", err)
+	}
+
+	if err := h.validator.ValidateStruct(c.Context(), req); err != nil {
+		return fmt.Errorf("invite user validation: %w", err)
+	}
+
+	inv, err := h.inviter.Invite(c.Context(), req.Email, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrEmailAlreadyTaken):
+			return validation.Errors{"email": {"email already taken"}}
+		default:
+			return fmt.Errorf("invite user: %w", err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		response.New(response.ToInvitation(*inv)),
+	)
+}
+
 func (h *UserHandler) GetByEmail(c fiber.Ctx) error {
 	email := c.Params("email")
 
@@ -50,3 +222,31 @@ func (h *UserHandler) GetByEmail(c fiber.Ctx) error {
 		Data: res,
 	})
 }
+
+// SetTimezone stores the authenticated user's preferred timezone, used for
+// requests without an X-Timezone header.
+func (h *UserHandler) SetTimezone(c fiber.Ctx) error {
+	userID, ok := UserIDFromCtx(c.Context())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized.")
+	}
+
+	var req request.SetTimezone
+
+	if err := c.Bind().Body(&req); err != nil {
+		return fmt.Errorf("set timezone bind request body: %w", err)
+	}
+
+	if err := h.svc.SetTimezone(c.Context(), userID, req.Timezone); err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidTimezone):
+			return validation.Errors{"timezone": {"timezone must be a valid IANA timezone"}}
+		case errors.Is(err, user.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "User not found.")
+		default:
+			return fmt.Errorf("set timezone: %w", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
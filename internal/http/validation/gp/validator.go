@@ -72,6 +72,25 @@ func New() (*Validator, error) {
 		return nil, fmt.Errorf("register [rfc3339] validation translation error: %w", err)
 	}
 
+	// type checks the kind of a value decoded from JSON, as in "type=number"
+	if err = instance.RegisterValidation("type", isJSONType); err != nil {
+		return nil, fmt.Errorf("register [type] validation error: %w", err)
+	}
+	err = instance.RegisterTranslation(
+		"type",
+		enTranslator,
+		func(ut ut.Translator) error {
+			return ut.Add("type", "{0} must be of type {1}", true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T("type", fe.Field(), fe.Param())
+			return t
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("register [type] validation translation error: %w", err)
+	}
+
 	registerOptionalType[string](instance)
 	registerOptionalType[int](instance)
 	registerOptionalType[bool](instance)
@@ -89,17 +108,45 @@ func (g *Validator) ValidateStruct(ctx context.Context, s any) error {
 	return g.buildErrors(ctx, err)
 }
 
+func (g *Validator) ValidateMap(ctx context.Context, data map[string]any, rules map[string]string) error {
+	trans := g.translator(ctx)
+	errs := make(validation.Errors)
+
+	for field, v := range data {
+		err := g.validator.VarCtx(ctx, v, rules[field])
+
+		var vErrs validator.ValidationErrors
+		if !errors.As(err, &vErrs) {
+			continue
+		}
+
+		// Var() has no field name, so the translation starts with an empty {0}
+		for _, vErr := range vErrs {
+			errs[field] = append(errs[field], field+" "+strings.TrimSpace(vErr.Translate(trans)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (g *Validator) translator(ctx context.Context) ut.Translator {
+	trans, found := g.uni.GetTranslator(http.Locale(ctx))
+	if !found {
+		trans, _ = g.uni.GetTranslator("en")
+	}
+	return trans
+}
+
 func (g *Validator) buildErrors(ctx context.Context, err error) error {
 	if err != nil {
 		var vErrs validator.ValidationErrors
 
-		locale := http.Locale(ctx)
-
 		if errors.As(err, &vErrs) {
-			trans, found := g.uni.GetTranslator(locale)
-			if !found {
-				trans, _ = g.uni.GetTranslator("en")
-			}
+			trans := g.translator(ctx)
 
 			errs := make(validation.Errors, len(vErrs))
 			for _, vErr := range vErrs {
@@ -114,6 +161,23 @@ func (g *Validator) buildErrors(ctx context.Context, err error) error {
 	return nil
 }
 
+// isJSONType reports whether the field holds a JSON value of the type in
+// the param: string, number or bool.
+func isJSONType(fl validator.FieldLevel) bool {
+	kind := fl.Field().Kind()
+
+	switch fl.Param() {
+	case "string":
+		return kind == reflect.String
+	case "number":
+		return kind == reflect.Float64
+	case "bool":
+		return kind == reflect.Bool
+	default:
+		panic(fmt.Sprintf("unknown type %q", fl.Param()))
+	}
+}
+
 func registerOptionalType[T any](validator *validator.Validate) {
 	var z T
 	t := reflect.TypeOf(z)
@@ -8,6 +8,9 @@ import (
 
 type Validator interface {
 	ValidateStruct(ctx context.Context, s any) error
+	// ValidateMap validates each value in data against the tag in rules with
+	// the same key. Values without a rule are not checked.
+	ValidateMap(ctx context.Context, data map[string]any, rules map[string]string) error
 }
 
 type Errors map[string][]string
@@ -0,0 +1,127 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image too large")
+)
+
+// maxPixels guards against decompression bombs.
+const maxPixels = 40_000_000
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Decode reads at most maxBytes from r, sniffs the content type from the data
+// itself (not the file name or client header) and decodes the image.
+func Decode(r io.Reader, maxBytes int64) (image.Image, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("read image: %w", err)
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, "", ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, contentType, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, contentType, ErrUnsupportedType
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, contentType, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, contentType, ErrUnsupportedType
+	}
+
+	return img, contentType, nil
+}
+
+// Thumbnail center-crops img to a square and scales it down to size×size
+// using an area-averaging (box) filter. Smaller images are scaled up with the
+// same sampling, which degrades to nearest-neighbour.
+func Thumbnail(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	scale := float64(side) / float64(size)
+
+	for dy := 0; dy < size; dy++ {
+		sy0 := y0 + int(float64(dy)*scale)
+		sy1 := max(y0+int(float64(dy+1)*scale), sy0+1)
+
+		for dx := 0; dx < size; dx++ {
+			sx0 := x0 + int(float64(dx)*scale)
+			sx1 := max(x0+int(float64(dx+1)*scale), sx0+1)
+
+			dst.SetNRGBA(dx, dy, average(img, sx0, sy0, sx1, sy1))
+		}
+	}
+
+	return dst
+}
+
+// average returns the mean colour of the [x0,x1)×[y0,y1) source block,
+// weighting colour channels by alpha so transparent pixels don't bleed.
+func average(img image.Image, x0, y0, x1, y1 int) color.NRGBA {
+	var r, g, b, a, n uint64
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA() // premultiplied, 16 bit
+			r += uint64(pr)
+			g += uint64(pg)
+			b += uint64(pb)
+			a += uint64(pa)
+			n++
+		}
+	}
+
+	if a == 0 {
+		return color.NRGBA{}
+	}
+
+	return color.NRGBA{
+		R: uint8(r * 0xff / a),
+		G: uint8(g * 0xff / a),
+		B: uint8(b * 0xff / a),
+		A: uint8(a / n >> 8),
+	}
+}
+
+// EncodePNG encodes img as PNG.
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,17 @@
+package invoice
+
+import (
+	"github.com/google/uuid"
+)
+
+type Created struct {
+	ID uuid.UUID
+}
+
+type Updated struct {
+	ID uuid.UUID
+}
+
+type Deleted struct {
+	ID uuid.UUID
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/db"
 	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/logger"
@@ -18,11 +19,15 @@ import (
 
 type invoiceModel struct {
 	ID         uuid.UUID
+	TenantID   uuid.UUID `gorm:"type:char(36);not nullable;index"`
 	CustomerID optional.Optional[uuid.UUID]
 	Amount     float64
 	Status     string
 	Date       *time.Time
+	PaidAt     *time.Time
 	IsActive   optional.Optional[bool]
+
+	CustomFields customfield.Values `gorm:"type:json"`
 }
 
 func (i *invoiceModel) BeforeCreate(*gorm.DB) (err error) {
@@ -40,22 +45,30 @@ func (*invoiceModel) TableName() string {
 func toModel(i Invoice) invoiceModel {
 	return invoiceModel{
 		ID:         i.ID,
+		TenantID:   i.TenantID,
 		CustomerID: optional.FromPtr(i.CustomerID),
 		Amount:     i.Amount,
 		Status:     i.Status,
 		Date:       i.Date,
+		PaidAt:     i.PaidAt,
 		IsActive:   optional.FromPtr(i.IsActive),
+
+		CustomFields: i.CustomFields,
 	}
 }
 
 func toEntity(i invoiceModel) Invoice {
 	return Invoice{
 		ID:         i.ID,
+		TenantID:   i.TenantID,
 		CustomerID: &i.CustomerID.Val,
 		Amount:     i.Amount,
 		Status:     i.Status,
 		Date:       i.Date,
+		PaidAt:     i.PaidAt,
 		IsActive:   &i.IsActive.Val,
+
+		CustomFields: i.CustomFields,
 	}
 }
 
@@ -117,14 +130,15 @@ func (s *GormStore) Search(ctx context.Context, req SearchFilter, page listing.P
 
 	q := s.DB(ctx).
 		Model(&invoiceModel{}).
-		Joins("JOIN customers ON invoices.customer_id = customers.id").
-		Where(`
+		Joins("JOIN customers ON invoices.customer_id = customers.id AND customers.tenant_id = invoices.tenant_id").
+		Where(`(
 			customers.name LIKE @search OR
 			customers.email LIKE @search OR
 			CAST(invoices.amount AS CHAR) LIKE @search OR
 			CAST(invoices.date AS CHAR) LIKE @search OR
 			invoices.status LIKE @search
-		`, sql.Named("search", "%"+req.Text+"%")).
+		)`, sql.Named("search", "%"+req.Text+"%")).
+		Scopes(customfield.Scope("invoices.custom_fields", req.CustomFields)).
 		Order("invoices.date " + sort)
 
 	var total int64
@@ -216,7 +230,7 @@ func (s *GormStore) ListWithCustomerInfo(ctx context.Context, sort listing.SortO
 			customers.email as customer_email,
 			customers.image_url as customer_image_url
 		`).
-		Joins("LEFT JOIN customers ON invoices.customer_id = customers.id").
+		Joins("LEFT JOIN customers ON invoices.customer_id = customers.id AND customers.tenant_id = invoices.tenant_id").
 		Order("date " + sortOrder).
 		Find(&out).Error
 
@@ -226,3 +240,40 @@ func (s *GormStore) ListWithCustomerInfo(ctx context.Context, sort listing.SortO
 
 	return out, nil
 }
+
+func (s *GormStore) FindWithCustomerInfo(ctx context.Context, id uuid.UUID) (*WithCustomerInfo, error) {
+	var out []WithCustomerInfo
+
+	err := s.DB(ctx).
+		Model(&invoiceModel{}).
+		Select(`
+			invoices.*,
+			customers.name as customer_name,
+			customers.email as customer_email,
+			customers.image_url as customer_image_url
+		`).
+		Joins("LEFT JOIN customers ON invoices.customer_id = customers.id AND customers.tenant_id = invoices.tenant_id").
+		Where("invoices.id = ?", id).
+		Limit(1).
+		Find(&out).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query invoice with customer info: %w", err)
+	}
+
+	if len(out) == 0 {
+		return nil, ErrInvoiceNotFound
+	}
+
+	return &out[0], nil
+}
+
+func (s *GormStore) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]Invoice, error) {
+	var models []invoiceModel
+
+	if err := s.DB(ctx).Where("customer_id = ?", customerID).Order("date").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query customer invoices: %w", err)
+	}
+
+	return toEntities(models), nil
+}
@@ -4,13 +4,24 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customfield"
+)
+
+const (
+	StatusPending = "pending"
+	StatusPaid    = "paid"
 )
 
 type Invoice struct {
 	ID         uuid.UUID
+	TenantID   uuid.UUID
 	CustomerID *uuid.UUID
 	Amount     float64
 	Status     string
 	Date       *time.Time
+	PaidAt     *time.Time
 	IsActive   *bool
+
+	CustomFields customfield.Values
 }
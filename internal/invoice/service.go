@@ -3,23 +3,28 @@ package invoice
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/event"
 	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/optional"
 )
 
 var ErrInvalidCustomerID = fmt.Errorf("invalid customer id")
 
 type Service struct {
 	store  Store
+	event  event.Publisher
 	logger logger.Logger
 }
 
-func NewService(store Store, logger logger.Logger) *Service {
+func NewService(store Store, evt event.Publisher, logger logger.Logger) *Service {
 	return &Service{
 		store:  store,
+		event:  evt,
 		logger: logger.With("component", "service.invoice"),
 	}
 }
@@ -33,6 +38,24 @@ func (s *Service) ListWithCustomerInfo(ctx context.Context, sort listing.SortOrd
 	return out, nil
 }
 
+func (s *Service) GetWithCustomerInfo(ctx context.Context, id uuid.UUID) (*WithCustomerInfo, error) {
+	out, err := s.store.FindWithCustomerInfo(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find invoice with customer info: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *Service) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]Invoice, error) {
+	out, err := s.store.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list customer invoices: %w", err)
+	}
+
+	return out, nil
+}
+
 func (s *Service) Search(ctx context.Context, req SearchFilter, page listing.Page) (listing.Result[Invoice], error) {
 	invs, total, err := s.store.Search(ctx, req, page)
 	if err != nil {
@@ -62,21 +85,35 @@ func (s *Service) Create(ctx context.Context, inv Invoice) (*Invoice, error) {
 		return nil, ErrInvalidCustomerID
 	}
 
+	if inv.Status == StatusPaid && inv.PaidAt == nil {
+		now := time.Now()
+		inv.PaidAt = &now
+	}
+
 	i, err := s.store.Insert(ctx, inv)
 	if err != nil {
 		return nil, fmt.Errorf("save invoice: %w", err)
 	}
 
+	if err = s.event.Publish(ctx, Created{ID: i.ID}); err != nil {
+		return nil, fmt.Errorf("publish event: %w", err)
+	}
+
 	return i, nil
 }
 
 func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateInput) (*Invoice, error) {
-	exists, err := s.Exists(ctx, id)
+	curr, err := s.store.Find(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("exists invoice: %w", err)
+		return nil, fmt.Errorf("find invoice: %w", err)
 	}
-	if !exists {
-		return nil, ErrInvoiceNotFound
+
+	// track when the invoice was settled
+	switch {
+	case req.Status == StatusPaid && curr.Status != StatusPaid:
+		req.PaidAt = optional.Of(time.Now())
+	case req.Status != "" && req.Status != StatusPaid && curr.PaidAt != nil:
+		req.PaidAt = optional.FromPtr[time.Time](nil)
 	}
 
 	if err = s.store.Update(ctx, id, req); err != nil {
@@ -88,6 +125,10 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateInput) (*I
 		return nil, fmt.Errorf("get invoice: %w", err)
 	}
 
+	if err = s.event.Publish(ctx, Updated{ID: id}); err != nil {
+		return nil, fmt.Errorf("publish event: %w", err)
+	}
+
 	return inv, nil
 }
 
@@ -103,5 +144,9 @@ func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 	if err = s.store.Delete(ctx, id); err != nil {
 		return fmt.Errorf("delete invoice: %w", err)
 	}
+
+	if err = s.event.Publish(ctx, Deleted{ID: id}); err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/gelozr/go-dash/internal/customfield"
 	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/optional"
 )
@@ -23,11 +24,14 @@ type Store interface {
 	Delete(context.Context, uuid.UUID) error
 
 	ListWithCustomerInfo(context.Context, listing.SortOrder) ([]WithCustomerInfo, error)
+	FindWithCustomerInfo(ctx context.Context, id uuid.UUID) (*WithCustomerInfo, error)
+	ListByCustomer(context.Context, uuid.UUID) ([]Invoice, error)
 }
 
 type SearchFilter struct {
-	Text string
-	Sort listing.SortOrder
+	Text         string
+	Sort         listing.SortOrder
+	CustomFields customfield.Filters
 }
 
 type UpdateInput struct {
@@ -35,7 +39,10 @@ type UpdateInput struct {
 	Amount     float64
 	Status     string
 	Date       optional.Optional[time.Time]
+	PaidAt     optional.Optional[time.Time]
 	IsActive   optional.Optional[bool]
+
+	CustomFields optional.Optional[customfield.Values]
 }
 
 type WithCustomerInfo struct {
@@ -0,0 +1,225 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type roleModel struct {
+	ID   uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	Name string    `gorm:"type:varchar(64);not nullable;unique"`
+}
+
+func (*roleModel) TableName() string {
+	return "roles"
+}
+
+type rolePermissionModel struct {
+	RoleID     uuid.UUID `gorm:"type:char(36);not nullable;primary_key"`
+	Permission string    `gorm:"type:varchar(64);not nullable;primary_key"`
+}
+
+func (*rolePermissionModel) TableName() string {
+	return "role_permissions"
+}
+
+type userRoleModel struct {
+	UserID uuid.UUID `gorm:"type:char(36);not nullable;primary_key"`
+	RoleID uuid.UUID `gorm:"type:char(36);not nullable;primary_key;index"`
+}
+
+func (*userRoleModel) TableName() string {
+	return "user_roles"
+}
+
+type GormStore struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+var _ Store = (*GormStore)(nil)
+
+func NewStore(db *gorm.DB, log logger.Logger) *GormStore {
+	return &GormStore{
+		db:     db,
+		logger: log.With("component", "store.gorm.rbac"),
+	}
+}
+
+func (s *GormStore) DB(ctx context.Context) *gorm.DB {
+	if gormDB, ok := db.FromCtx(ctx); ok {
+		return gormDB.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+func (s *GormStore) ListRoles(ctx context.Context) ([]Role, error) {
+	var models []roleModel
+
+	if err := s.DB(ctx).Order("name").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query roles: %w", err)
+	}
+
+	return s.withPermissions(ctx, models)
+}
+
+func (s *GormStore) FindRolesByName(ctx context.Context, names []string) ([]Role, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var models []roleModel
+
+	if err := s.DB(ctx).Where("name IN ?", names).Order("name").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query roles by name: %w", err)
+	}
+
+	return s.withPermissions(ctx, models)
+}
+
+func (s *GormStore) ListUserRoles(ctx context.Context, userID uuid.UUID) ([]Role, error) {
+	var models []roleModel
+
+	err := s.DB(ctx).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&models).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query user roles: %w", err)
+	}
+
+	return s.withPermissions(ctx, models)
+}
+
+func (s *GormStore) SetUserRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error {
+	return s.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&userRoleModel{}).Error; err != nil {
+			return fmt.Errorf("delete user roles: %w", err)
+		}
+
+		if len(roleIDs) == 0 {
+			return nil
+		}
+
+		models := make([]userRoleModel, len(roleIDs))
+		for i, id := range roleIDs {
+			models[i] = userRoleModel{UserID: userID, RoleID: id}
+		}
+
+		if err := tx.Create(&models).Error; err != nil {
+			return fmt.Errorf("insert user roles: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *GormStore) ListRoleUsersForUpdate(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := s.DB(ctx).
+		Model(&userRoleModel{}).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("role_id = ?", roleID).
+		Pluck("user_id", &ids).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("list role users for update: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (s *GormStore) InsertRoleIfMissing(ctx context.Context, r Role) error {
+	return s.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		model := roleModel{ID: uuid.New(), Name: r.Name}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model)
+		if res.Error != nil {
+			return fmt.Errorf("insert role: %w", res.Error)
+		}
+		if res.RowsAffected == 0 || len(r.Permissions) == 0 {
+			return nil
+		}
+
+		perms := make([]rolePermissionModel, len(r.Permissions))
+		for i, p := range r.Permissions {
+			perms[i] = rolePermissionModel{RoleID: model.ID, Permission: string(p)}
+		}
+
+		if err := tx.Create(&perms).Error; err != nil {
+			return fmt.Errorf("insert role permissions: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *GormStore) AddRolePermissions(ctx context.Context, name string, perms []Permission) error {
+	if len(perms) == 0 {
+		return nil
+	}
+
+	var model roleModel
+
+	if err := s.DB(ctx).Where("name = ?", name).Take(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return fmt.Errorf("query role: %w", err)
+	}
+
+	models := make([]rolePermissionModel, len(perms))
+	for i, p := range perms {
+		models[i] = rolePermissionModel{RoleID: model.ID, Permission: string(p)}
+	}
+
+	if err := s.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models).Error; err != nil {
+		return fmt.Errorf("insert role permissions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) withPermissions(ctx context.Context, models []roleModel) ([]Role, error) {
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+
+	var perms []rolePermissionModel
+
+	if err := s.DB(ctx).Where("role_id IN ?", ids).Order("permission").Find(&perms).Error; err != nil {
+		return nil, fmt.Errorf("query role permissions: %w", err)
+	}
+
+	byRole := make(map[uuid.UUID][]Permission, len(models))
+	for _, p := range perms {
+		byRole[p.RoleID] = append(byRole[p.RoleID], Permission(p.Permission))
+	}
+
+	out := make([]Role, len(models))
+	for i, m := range models {
+		out[i] = Role{
+			ID:          m.ID,
+			Name:        m.Name,
+			Permissions: byRole[m.ID],
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package rbac
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Permission is a "<resource>:<action>" capability granted through roles.
+type Permission string
+
+const (
+	ReportsRead Permission = "reports:read"
+
+	CustomersRead   Permission = "customers:read"
+	CustomersWrite  Permission = "customers:write"
+	CustomersDelete Permission = "customers:delete"
+
+	InvoicesRead   Permission = "invoices:read"
+	InvoicesWrite  Permission = "invoices:write"
+	InvoicesDelete Permission = "invoices:delete"
+
+	CustomFieldsWrite Permission = "custom_fields:write"
+
+	UsersRead   Permission = "users:read"
+	UsersWrite  Permission = "users:write"
+	UsersDelete Permission = "users:delete"
+	RolesManage Permission = "roles:manage"
+
+	SessionsManage Permission = "sessions:manage"
+	TenantsManage  Permission = "tenants:manage"
+
+	SystemMonitor Permission = "system:monitor"
+)
+
+// Permissions lists every permission, in display order.
+var Permissions = []Permission{
+	ReportsRead,
+	CustomersRead, CustomersWrite, CustomersDelete,
+	InvoicesRead, InvoicesWrite, InvoicesDelete,
+	CustomFieldsWrite,
+	UsersRead, UsersWrite, UsersDelete, RolesManage,
+	SessionsManage, TenantsManage,
+	SystemMonitor,
+}
+
+func (p Permission) Valid() bool {
+	return slices.Contains(Permissions, p)
+}
+
+const (
+	RoleAdmin      = "admin"
+	RoleAccountant = "accountant"
+	RoleViewer     = "viewer"
+)
+
+type Role struct {
+	ID          uuid.UUID
+	Name        string
+	Permissions []Permission
+}
+
+// DefaultRoles are created on startup when missing. Their permissions are
+// only set on creation, so changes made in the database are kept; the admin
+// role is the exception and always holds every permission.
+var DefaultRoles = []Role{
+	{
+		Name:        RoleAdmin,
+		Permissions: Permissions,
+	},
+	{
+		Name: RoleAccountant,
+		Permissions: []Permission{
+			ReportsRead,
+			CustomersRead, CustomersWrite,
+			InvoicesRead, InvoicesWrite, InvoicesDelete,
+			UsersRead,
+		},
+	},
+	{
+		Name:        RoleViewer,
+		Permissions: []Permission{ReportsRead, CustomersRead, InvoicesRead},
+	},
+}
+
+// Grants are the roles of a user and the permissions they add up to.
+type Grants struct {
+	Roles       []string
+	Permissions []Permission
+}
+
+func grantsOf(roles []Role) Grants {
+	var g Grants
+	for _, r := range roles {
+		g.Roles = append(g.Roles, r.Name)
+		for _, p := range r.Permissions {
+			if !slices.Contains(g.Permissions, p) {
+				g.Permissions = append(g.Permissions, p)
+			}
+		}
+	}
+	return g
+}
@@ -0,0 +1,124 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type Service struct {
+	store     Store
+	txManager db.TxManager
+	logger    logger.Logger
+}
+
+func NewService(store Store, txManager db.TxManager, log logger.Logger) *Service {
+	return &Service{
+		store:     store,
+		txManager: txManager,
+		logger:    log.With("component", "service.rbac"),
+	}
+}
+
+// SeedDefaults creates the default roles that do not exist yet and grants
+// the admin role any permission it lacks.
+func (s *Service) SeedDefaults(ctx context.Context) error {
+	for _, r := range DefaultRoles {
+		if err := s.store.InsertRoleIfMissing(ctx, r); err != nil {
+			return fmt.Errorf("seed role %s: %w", r.Name, err)
+		}
+	}
+
+	// permissions added in later releases reach existing installs
+	if err := s.store.AddRolePermissions(ctx, RoleAdmin, Permissions); err != nil {
+		return fmt.Errorf("grant admin permissions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ListRoles(ctx context.Context) ([]Role, error) {
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *Service) UserRoles(ctx context.Context, userID uuid.UUID) ([]Role, error) {
+	roles, err := s.store.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Grants returns the roles and permissions of the user.
+func (s *Service) Grants(ctx context.Context, userID uuid.UUID) (Grants, error) {
+	roles, err := s.UserRoles(ctx, userID)
+	if err != nil {
+		return Grants{}, err
+	}
+	return grantsOf(roles), nil
+}
+
+// SetUserRoles replaces the roles of the user. Unknown names fail with
+// ErrRoleNotFound; taking the admin role from its only holder with
+// ErrLastAdmin.
+func (s *Service) SetUserRoles(ctx context.Context, userID uuid.UUID, names []string) ([]Role, error) {
+	names = slices.Compact(slices.Sorted(slices.Values(names)))
+
+	var roles []Role
+
+	err := s.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+
+		roles, err = s.store.FindRolesByName(ctx, names)
+		if err != nil {
+			return fmt.Errorf("find roles: %w", err)
+		}
+		if len(roles) != len(names) {
+			return ErrRoleNotFound
+		}
+
+		current, err := s.store.ListUserRoles(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("list user roles: %w", err)
+		}
+
+		isAdmin := func(r Role) bool { return r.Name == RoleAdmin }
+
+		if i := slices.IndexFunc(current, isAdmin); i >= 0 && !slices.ContainsFunc(roles, isAdmin) {
+			// locked so concurrent demotions wait instead of both seeing another admin
+			admins, err := s.store.ListRoleUsersForUpdate(ctx, current[i].ID)
+			if err != nil {
+				return fmt.Errorf("list admins: %w", err)
+			}
+			if len(admins) <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		ids := make([]uuid.UUID, len(roles))
+		for i, r := range roles {
+			ids[i] = r.ID
+		}
+
+		if err := s.store.SetUserRoles(ctx, userID, ids); err != nil {
+			return fmt.Errorf("set user roles: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,28 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrLastAdmin    = errors.New("cannot remove the last admin")
+)
+
+type Store interface {
+	ListRoles(ctx context.Context) ([]Role, error)
+	FindRolesByName(ctx context.Context, names []string) ([]Role, error)
+	ListUserRoles(ctx context.Context, userID uuid.UUID) ([]Role, error)
+	SetUserRoles(ctx context.Context, userID uuid.UUID, roleIDs []uuid.UUID) error
+	// ListRoleUsersForUpdate returns the users holding the role and locks
+	// their user_roles rows until the transaction of ctx ends.
+	ListRoleUsersForUpdate(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error)
+	// InsertRoleIfMissing creates the role with its permissions unless a
+	// role of that name exists.
+	InsertRoleIfMissing(ctx context.Context, r Role) error
+	// AddRolePermissions grants the permissions the named role lacks.
+	AddRolePermissions(ctx context.Context, name string, perms []Permission) error
+}
@@ -0,0 +1,117 @@
+package statement
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+var htmlTmpl = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format(time.DateOnly) },
+	"money": money,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Statement of account</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 14px; color: #111; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+td.num, th.num { text-align: right; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Statement of account</h1>
+<p>
+<strong>{{.Customer.Name}}</strong><br>
+{{.Customer.Email}}<br>
+Period: {{date .From}} to {{date .To}}
+</p>
+<table>
+<thead>
+<tr><th>Date</th><th>Description</th><th class="num">Debit</th><th class="num">Credit</th><th class="num">Balance</th></tr>
+</thead>
+<tbody>
+<tr><td>{{date .From}}</td><td>Opening balance</td><td></td><td></td><td class="num">{{money .OpeningBalance}}</td></tr>
+{{- range .Lines}}
+<tr><td>{{date .Date}}</td><td>{{.Description}}</td><td class="num">{{if .Debit}}{{money .Debit}}{{end}}</td><td class="num">{{if .Credit}}{{money .Credit}}{{end}}</td><td class="num">{{money .Balance}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td>{{date .To}}</td><td>Closing balance</td><td class="num">{{money .TotalDebit}}</td><td class="num">{{money .TotalCredit}}</td><td class="num">{{money .ClosingBalance}}</td></tr>
+</tfoot>
+</table>
+<p><small>Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</small></p>
+</body>
+</html>
+`))
+
+// RenderHTML writes the statement as a standalone HTML document.
+func RenderHTML(w io.Writer, s Statement) error {
+	if err := htmlTmpl.Execute(w, s); err != nil {
+		return fmt.Errorf("render statement html: %w", err)
+	}
+	return nil
+}
+
+// RenderText writes the statement as a fixed-width plain text table.
+func RenderText(w io.Writer, s Statement) error {
+	for _, l := range textLines(s) {
+		if _, err := io.WriteString(w, l+"\n"); err != nil {
+			return fmt.Errorf("render statement text: %w", err)
+		}
+	}
+	return nil
+}
+
+// RenderPDF writes the statement as a PDF document.
+func RenderPDF(w io.Writer, s Statement) error {
+	if err := writePDF(w, textLines(s)); err != nil {
+		return fmt.Errorf("render statement pdf: %w", err)
+	}
+	return nil
+}
+
+func textLines(s Statement) []string {
+	const row = "%-10s  %-36s  %12s  %12s  %12s"
+
+	out := []string{
+		"STATEMENT OF ACCOUNT",
+		"",
+		s.Customer.Name,
+		s.Customer.Email,
+		fmt.Sprintf("Period: %s to %s", s.From.Format(time.DateOnly), s.To.Format(time.DateOnly)),
+		"",
+		fmt.Sprintf(row, "Date", "Description", "Debit", "Credit", "Balance"),
+		strings.Repeat("-", 90),
+		fmt.Sprintf(row, s.From.Format(time.DateOnly), "Opening balance", "", "", money(s.OpeningBalance)),
+	}
+
+	for _, l := range s.Lines {
+		debit, credit := "", ""
+		if l.Debit != 0 {
+			debit = money(l.Debit)
+		}
+		if l.Credit != 0 {
+			credit = money(l.Credit)
+		}
+		out = append(out, fmt.Sprintf(row, l.Date.Format(time.DateOnly), l.Description, debit, credit, money(l.Balance)))
+	}
+
+	out = append(out,
+		strings.Repeat("-", 90),
+		fmt.Sprintf(row, s.To.Format(time.DateOnly), "Closing balance", money(s.TotalDebit), money(s.TotalCredit), money(s.ClosingBalance)),
+		"",
+		"Generated "+s.GeneratedAt.Format("2006-01-02 15:04 MST"),
+	)
+
+	return out
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
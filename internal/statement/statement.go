@@ -0,0 +1,123 @@
+package statement
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/customer"
+	"github.com/gelozr/go-dash/internal/invoice"
+)
+
+type LineKind string
+
+const (
+	KindInvoice LineKind = "invoice"
+	KindPayment LineKind = "payment"
+)
+
+// Line is a single movement on the account. Invoices are debits, payments are
+// credits; Balance is the running balance after the line.
+type Line struct {
+	Date        time.Time
+	Kind        LineKind
+	Reference   uuid.UUID
+	Description string
+	Debit       float64
+	Credit      float64
+	Balance     float64
+}
+
+type Statement struct {
+	Customer       customer.Customer
+	From           time.Time
+	To             time.Time
+	OpeningBalance float64
+	Lines          []Line
+	TotalDebit     float64
+	TotalCredit    float64
+	ClosingBalance float64
+	GeneratedAt    time.Time
+}
+
+// Build produces the statement of account for [from, to].
+//
+// Every invoice is charged on its date. A paid invoice is settled on its
+// PaidAt date, or on its invoice date for invoices paid before PaidAt was
+// recorded. Movements before from are summed into the opening balance.
+func Build(cust customer.Customer, invs []invoice.Invoice, from, to time.Time) Statement {
+	var movements []Line
+
+	for _, inv := range invs {
+		if inv.Date == nil {
+			continue
+		}
+
+		movements = append(movements, Line{
+			Date:        *inv.Date,
+			Kind:        KindInvoice,
+			Reference:   inv.ID,
+			Description: "Invoice " + shortID(inv.ID),
+			Debit:       inv.Amount,
+		})
+
+		if inv.Status != invoice.StatusPaid {
+			continue
+		}
+
+		paidAt := *inv.Date
+		if inv.PaidAt != nil {
+			paidAt = *inv.PaidAt
+		}
+
+		movements = append(movements, Line{
+			Date:        paidAt,
+			Kind:        KindPayment,
+			Reference:   inv.ID,
+			Description: "Payment for invoice " + shortID(inv.ID),
+			Credit:      inv.Amount,
+		})
+	}
+
+	// charges come before payments made on the same instant
+	sort.SliceStable(movements, func(i, j int) bool {
+		if movements[i].Date.Equal(movements[j].Date) {
+			return movements[i].Kind == KindInvoice && movements[j].Kind == KindPayment
+		}
+		return movements[i].Date.Before(movements[j].Date)
+	})
+
+	s := Statement{
+		Customer:    cust,
+		From:        from,
+		To:          to,
+		Lines:       []Line{},
+		GeneratedAt: time.Now(),
+	}
+
+	balance := 0.0
+	for _, m := range movements {
+		switch {
+		case m.Date.Before(from):
+			balance += m.Debit - m.Credit
+			s.OpeningBalance = balance
+		case m.Date.After(to):
+			continue
+		default:
+			balance += m.Debit - m.Credit
+			m.Balance = balance
+			s.TotalDebit += m.Debit
+			s.TotalCredit += m.Credit
+			s.Lines = append(s.Lines, m)
+		}
+	}
+
+	s.ClosingBalance = s.OpeningBalance + s.TotalDebit - s.TotalCredit
+
+	return s
+}
+
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/gelozr/go-dash/internal/config"
+)
+
+type Driver string
+
+const (
+	Local = Driver("local")
+	S3    = Driver("s3")
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage stores objects under slash separated keys, e.g. "avatars/<id>/256.png".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+type Manager interface {
+	Storage
+	RegisterDriver(Driver, Storage) error
+	Disk(Driver) (Storage, error)
+}
+
+type manager struct {
+	mu            sync.RWMutex
+	disks         map[Driver]Storage
+	defaultDriver Driver
+}
+
+func NewManager(cfg *config.Config) Manager {
+	disks := make(map[Driver]Storage)
+	disks[Local] = NewLocalStorage(cfg)
+	disks[S3] = NewS3Storage(cfg)
+
+	return &manager{
+		disks:         disks,
+		defaultDriver: getDefaultDriver(cfg),
+	}
+}
+
+func (m *manager) Disk(driver Driver) (Storage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if d, ok := m.disks[driver]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("storage not found for driver %s", driver)
+}
+
+func (m *manager) RegisterDriver(driver Driver, s Storage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.disks[driver]; ok {
+		return errors.New("storage driver already registered")
+	}
+
+	m.disks[driver] = s
+	return nil
+}
+
+func (m *manager) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	disk, err := m.Disk(m.defaultDriver)
+	if err != nil {
+		return err
+	}
+	return disk.Put(ctx, key, r, contentType)
+}
+
+func (m *manager) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	disk, err := m.Disk(m.defaultDriver)
+	if err != nil {
+		return nil, err
+	}
+	return disk.Get(ctx, key)
+}
+
+func (m *manager) Delete(ctx context.Context, key string) error {
+	disk, err := m.Disk(m.defaultDriver)
+	if err != nil {
+		return err
+	}
+	return disk.Delete(ctx, key)
+}
+
+func (m *manager) URL(key string) string {
+	disk, err := m.Disk(m.defaultDriver)
+	if err != nil {
+		return ""
+	}
+	return disk.URL(key)
+}
+
+func getDefaultDriver(cfg *config.Config) Driver {
+	defaultDriver := Local
+	if cfg.StorageDriver != "" {
+		defaultDriver = Driver(cfg.StorageDriver)
+	}
+	return defaultDriver
+}
@@ -0,0 +1,160 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+type tenantModel struct {
+	ID        uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	Name      string    `gorm:"type:varchar(255);not nullable"`
+	CreatedAt time.Time `gorm:"not nullable"`
+}
+
+func (*tenantModel) TableName() string {
+	return "tenants"
+}
+
+type memberModel struct {
+	TenantID  uuid.UUID `gorm:"type:char(36);not nullable;primary_key"`
+	UserID    uuid.UUID `gorm:"type:char(36);not nullable;primary_key;index"`
+	CreatedAt time.Time `gorm:"not nullable"`
+}
+
+func (*memberModel) TableName() string {
+	return "tenant_members"
+}
+
+func toEntity(m tenantModel) Tenant {
+	return Tenant{
+		ID:        m.ID,
+		Name:      m.Name,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+type GormStore struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+var _ Store = (*GormStore)(nil)
+
+func NewStore(db *gorm.DB, log logger.Logger) *GormStore {
+	return &GormStore{
+		db:     db,
+		logger: log.With("component", "store.gorm.tenant"),
+	}
+}
+
+func (s *GormStore) DB(ctx context.Context) *gorm.DB {
+	if gormDB, ok := db.FromCtx(ctx); ok {
+		return gormDB.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+func (s *GormStore) Find(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	var model tenantModel
+
+	if err := s.DB(ctx).First(&model, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrTenantNotFound
+		default:
+			return nil, fmt.Errorf("query tenant: %w", err)
+		}
+	}
+
+	t := toEntity(model)
+	return &t, nil
+}
+
+func (s *GormStore) ListForUser(ctx context.Context, userID uuid.UUID) ([]Tenant, error) {
+	var models []tenantModel
+
+	err := s.DB(ctx).
+		Joins("JOIN tenant_members ON tenant_members.tenant_id = tenants.id").
+		Where("tenant_members.user_id = ?", userID).
+		Order("tenants.name").
+		Find(&models).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query user tenants: %w", err)
+	}
+
+	out := make([]Tenant, len(models))
+	for i, m := range models {
+		out[i] = toEntity(m)
+	}
+
+	return out, nil
+}
+
+func (s *GormStore) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (bool, error) {
+	tx := s.DB(ctx).Model(&memberModel{}).Where("tenant_id = ? AND user_id = ?", tenantID, userID)
+
+	exists, err := db.RecordExists(tx)
+	if err != nil {
+		return false, fmt.Errorf("query membership: %w", err)
+	}
+	return exists, nil
+}
+
+func (s *GormStore) Insert(ctx context.Context, t Tenant) (*Tenant, error) {
+	model := tenantModel{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("insert tenant: %w", err)
+	}
+
+	t = toEntity(model)
+	return &t, nil
+}
+
+func (s *GormStore) AddMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	model := memberModel{
+		TenantID:  tenantID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model).Error; err != nil {
+		return fmt.Errorf("insert member: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	err := s.DB(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&memberModel{}).Error
+
+	if err != nil {
+		return fmt.Errorf("delete member: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) RemoveUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.DB(ctx).Where("user_id = ?", userID).Delete(&memberModel{}).Error; err != nil {
+		return fmt.Errorf("delete memberships: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package tenant
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Column holds the tenant of a row in every tenant table.
+const Column = "tenant_id"
+
+// Plugin scopes every query, update and delete on the tenant tables to the
+// tenant of the statement's context, and stamps it on created rows. A
+// statement without a tenant fails with ErrNoTenant, unless the context is
+// WithoutScope.
+//
+// Tables are recognised by the statement's main table; joined tables must
+// be matched on tenant_id in the join condition, and raw SQL is not scoped.
+type Plugin struct {
+	tables map[string]bool
+}
+
+func NewPlugin(tables ...string) *Plugin {
+	p := &Plugin{tables: make(map[string]bool, len(tables))}
+	for _, t := range tables {
+		p.tables[t] = true
+	}
+	return p
+}
+
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Query().Before("gorm:query").Register("tenant:scope_query", p.scope); err != nil {
+		return fmt.Errorf("register query callback: %w", err)
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:scope_row", p.scope); err != nil {
+		return fmt.Errorf("register row callback: %w", err)
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:scope_update", p.scope); err != nil {
+		return fmt.Errorf("register update callback: %w", err)
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:scope_delete", p.scope); err != nil {
+		return fmt.Errorf("register delete callback: %w", err)
+	}
+	if err := cb.Create().Before("gorm:create").Register("tenant:stamp_create", p.stamp); err != nil {
+		return fmt.Errorf("register create callback: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Plugin) scope(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || !p.tables[stmt.Table] || unscoped(stmt.Context) {
+		return
+	}
+
+	id, ok := FromCtx(stmt.Context)
+	if !ok {
+		_ = db.AddError(fmt.Errorf("%s: %w", stmt.Table, ErrNoTenant))
+		return
+	}
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: stmt.Table, Name: Column}, Value: id},
+	}})
+}
+
+func (p *Plugin) stamp(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || !p.tables[stmt.Table] {
+		return
+	}
+
+	id, ok := FromCtx(stmt.Context)
+	if !ok {
+		_ = db.AddError(fmt.Errorf("%s: %w", stmt.Table, ErrNoTenant))
+		return
+	}
+
+	if stmt.Schema == nil || stmt.Schema.LookUpField(Column) == nil {
+		_ = db.AddError(fmt.Errorf("%s: model has no %s field", stmt.Table, Column))
+		return
+	}
+
+	// sets every element when creating a slice
+	stmt.SetColumn(Column, id)
+}
@@ -0,0 +1,141 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/logger"
+)
+
+// ErrTenantRequired is returned by Resolve when the user belongs to several
+// tenants and did not choose one.
+var ErrTenantRequired = errors.New("tenant required")
+
+type Service struct {
+	store     Store
+	txManager db.TxManager
+	logger    logger.Logger
+}
+
+func NewService(store Store, txManager db.TxManager, log logger.Logger) *Service {
+	return &Service{
+		store:     store,
+		txManager: txManager,
+		logger:    log.With("component", "service.tenant"),
+	}
+}
+
+func (s *Service) ListForUser(ctx context.Context, userID uuid.UUID) ([]Tenant, error) {
+	tenants, err := s.store.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+// Resolve returns the tenant a request of the user acts in: the requested
+// one if the user is a member, or else the user's only tenant.
+func (s *Service) Resolve(ctx context.Context, userID, requested uuid.UUID) (uuid.UUID, error) {
+	if requested != uuid.Nil {
+		ok, err := s.store.IsMember(ctx, requested, userID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("is member: %w", err)
+		}
+		if !ok {
+			return uuid.Nil, ErrNotMember
+		}
+		return requested, nil
+	}
+
+	tenants, err := s.ListForUser(ctx, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	switch len(tenants) {
+	case 0:
+		return uuid.Nil, ErrNotMember
+	case 1:
+		return tenants[0].ID, nil
+	default:
+		return uuid.Nil, ErrTenantRequired
+	}
+}
+
+// Create creates a tenant with the owner as its first member.
+func (s *Service) Create(ctx context.Context, name string, ownerID uuid.UUID) (*Tenant, error) {
+	var t *Tenant
+
+	err := s.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+
+		t, err = s.store.Insert(ctx, Tenant{
+			ID:        uuid.New(),
+			Name:      name,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("insert tenant: %w", err)
+		}
+
+		if err = s.store.AddMember(ctx, t.ID, ownerID); err != nil {
+			return fmt.Errorf("add owner: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// IsMember reports whether the user belongs to the tenant.
+func (s *Service) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (bool, error) {
+	ok, err := s.store.IsMember(ctx, tenantID, userID)
+	if err != nil {
+		return false, fmt.Errorf("is member: %w", err)
+	}
+	return ok, nil
+}
+
+func (s *Service) AddMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	if _, err := s.store.Find(ctx, tenantID); err != nil {
+		return fmt.Errorf("find tenant: %w", err)
+	}
+
+	if err := s.store.AddMember(ctx, tenantID, userID); err != nil {
+		return fmt.Errorf("add member: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	if _, err := s.store.Find(ctx, tenantID); err != nil {
+		return fmt.Errorf("find tenant: %w", err)
+	}
+
+	if err := s.store.RemoveMember(ctx, tenantID, userID); err != nil {
+		return fmt.Errorf("remove member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveUser removes the user from all their tenants, as when the user is
+// deleted.
+func (s *Service) RemoveUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.store.RemoveUser(ctx, userID); err != nil {
+		return fmt.Errorf("remove user memberships: %w", err)
+	}
+
+	return nil
+}
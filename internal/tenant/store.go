@@ -0,0 +1,18 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Store interface {
+	Find(ctx context.Context, id uuid.UUID) (*Tenant, error)
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]Tenant, error)
+	IsMember(ctx context.Context, tenantID, userID uuid.UUID) (bool, error)
+	Insert(ctx context.Context, t Tenant) (*Tenant, error)
+	AddMember(ctx context.Context, tenantID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error
+	// RemoveUser removes the user from every tenant.
+	RemoveUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,53 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrNotMember      = errors.New("user is not a member of the tenant")
+	// ErrNoTenant is returned by queries on tenant tables when the context
+	// carries no tenant.
+	ErrNoTenant = errors.New("no tenant in context")
+)
+
+// Tenant is an organisation whose customers and invoices are isolated from
+// every other tenant.
+type Tenant struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
+type ctxKey string
+
+var (
+	tenantCtxKey   = ctxKey("tenant_id")
+	unscopedCtxKey = ctxKey("tenant_unscoped")
+)
+
+// WithID scopes queries made with the returned context to the tenant.
+func WithID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantCtxKey, id)
+}
+
+func FromCtx(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(tenantCtxKey).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
+
+// WithoutScope lets queries span all tenants. Only use it where the record
+// is identified by something other than the caller, such as a signed token.
+func WithoutScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedCtxKey, true)
+}
+
+func unscoped(ctx context.Context) bool {
+	v, _ := ctx.Value(unscopedCtxKey).(bool)
+	return v
+}
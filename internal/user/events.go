@@ -0,0 +1,15 @@
+package user
+
+import (
+	"github.com/google/uuid"
+)
+
+// Invited is published when someone is invited to create an account.
+type Invited struct {
+	ID uuid.UUID
+}
+
+// PasswordResetRequested is published when a user asks for a password reset link.
+type PasswordResetRequested struct {
+	ID uuid.UUID
+}
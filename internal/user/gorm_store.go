@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/logger"
 )
 
@@ -17,6 +20,112 @@ type userModel struct {
 	Name     string    `gorm:"type:varchar(255);not nullable"`
 	Email    string    `gorm:"type:varchar(255);not nullable;unique"`
 	Password string    `gorm:"type:text;not nullable"`
+	Timezone string    `gorm:"type:varchar(64);not nullable;default:''"`
+}
+
+type invitationModel struct {
+	ID         uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	Email      string    `gorm:"type:varchar(255);not nullable;index"`
+	InvitedBy  uuid.UUID `gorm:"type:char(36);not nullable"`
+	ExpiresAt  time.Time `gorm:"not nullable"`
+	AcceptedAt *time.Time
+	CreatedAt  time.Time `gorm:"not nullable"`
+}
+
+func (*invitationModel) TableName() string {
+	return "user_invitations"
+}
+
+func toInvitationEntity(m invitationModel) Invitation {
+	return Invitation{
+		ID:         m.ID,
+		Email:      m.Email,
+		InvitedBy:  m.InvitedBy,
+		ExpiresAt:  m.ExpiresAt,
+		AcceptedAt: m.AcceptedAt,
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+type passwordResetModel struct {
+	ID        uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	UserID    uuid.UUID `gorm:"type:char(36);not nullable;index"`
+	ExpiresAt time.Time `gorm:"not nullable"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"not nullable"`
+}
+
+func (*passwordResetModel) TableName() string {
+	return "password_resets"
+}
+
+func toPasswordResetEntity(m passwordResetModel) PasswordReset {
+	return PasswordReset{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		ExpiresAt: m.ExpiresAt,
+		UsedAt:    m.UsedAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+type twoFactorModel struct {
+	UserID         uuid.UUID `gorm:"type:char(36);not nullable;primary_key"`
+	Secret         string    `gorm:"type:varchar(64);not nullable"`
+	ConfirmedAt    *time.Time
+	LastStep       int64 `gorm:"not nullable;default:0"`
+	FailedAttempts int   `gorm:"not nullable;default:0"`
+	LockedUntil    *time.Time
+	CreatedAt      time.Time `gorm:"not nullable"`
+}
+
+func (*twoFactorModel) TableName() string {
+	return "user_two_factors"
+}
+
+func toTwoFactorEntity(m twoFactorModel) TwoFactor {
+	return TwoFactor{
+		UserID:         m.UserID,
+		Secret:         m.Secret,
+		ConfirmedAt:    m.ConfirmedAt,
+		LastStep:       m.LastStep,
+		FailedAttempts: m.FailedAttempts,
+		LockedUntil:    m.LockedUntil,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+type recoveryCodeModel struct {
+	ID     uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	UserID uuid.UUID `gorm:"type:char(36);not nullable;index"`
+	Hash   string    `gorm:"type:text;not nullable"`
+	UsedAt *time.Time
+}
+
+func (*recoveryCodeModel) TableName() string {
+	return "user_recovery_codes"
+}
+
+type loginChallengeModel struct {
+	ID        uuid.UUID `gorm:"type:char(36);not nullable;unique;primary_key"`
+	UserID    uuid.UUID `gorm:"type:char(36);not nullable;index"`
+	ExpiresAt time.Time `gorm:"not nullable"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"not nullable"`
+}
+
+func (*loginChallengeModel) TableName() string {
+	return "login_challenges"
+}
+
+func toLoginChallengeEntity(m loginChallengeModel) LoginChallenge {
+	return LoginChallenge{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		ExpiresAt: m.ExpiresAt,
+		UsedAt:    m.UsedAt,
+		CreatedAt: m.CreatedAt,
+	}
 }
 
 type GormStore struct {
@@ -40,6 +149,21 @@ func (s *GormStore) DB(ctx context.Context) *gorm.DB {
 	return s.db
 }
 
+func (s *GormStore) FindByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	var u User
+
+	if err := s.DB(ctx).First(&u, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrUserNotFound
+		default:
+			return nil, fmt.Errorf("query by id: %w", err)
+		}
+	}
+
+	return &u, nil
+}
+
 func (s *GormStore) FindByEmail(ctx context.Context, email string) (*User, error) {
 	var u User
 
@@ -54,3 +178,424 @@ func (s *GormStore) FindByEmail(ctx context.Context, email string) (*User, error
 
 	return &u, nil
 }
+
+func (s *GormStore) UpdateTimezone(ctx context.Context, id uuid.UUID, tz string) error {
+	res := s.DB(ctx).Model(&User{}).Where("id = ?", id).Update("timezone", tz)
+	if res.Error != nil {
+		return fmt.Errorf("update timezone: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *GormStore) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	tx := s.DB(ctx).Model(&User{}).Where("email = ?", email)
+
+	exists, err := db.RecordExists(tx)
+	if err != nil {
+		return false, fmt.Errorf("exists by email: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (s *GormStore) List(ctx context.Context, page listing.Page) ([]User, int64, error) {
+	q := s.DB(ctx).Model(&User{})
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	var users []User
+	if err := q.Order("name, email").Scopes(page.Scope()).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("query users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+func (s *GormStore) Insert(ctx context.Context, u User) (*User, error) {
+	if err := s.DB(ctx).Create(&u).Error; err != nil {
+		return nil, fmt.Errorf("store user: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (s *GormStore) Update(ctx context.Context, id uuid.UUID, req UpdateInput) error {
+	res := s.DB(ctx).Model(&User{}).Where("id = ?", id).Updates(req)
+	if res.Error != nil {
+		return fmt.Errorf("update user: %w", res.Error)
+	}
+
+	return nil
+}
+
+func (s *GormStore) Delete(ctx context.Context, id uuid.UUID) error {
+	res := s.DB(ctx).Delete(&User{}, "id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("delete user: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *GormStore) InsertInvitation(ctx context.Context, inv Invitation) (*Invitation, error) {
+	model := invitationModel{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: inv.ExpiresAt,
+		CreatedAt: inv.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store invitation: %w", err)
+	}
+
+	inv = toInvitationEntity(model)
+	return &inv, nil
+}
+
+func (s *GormStore) FindInvitation(ctx context.Context, id uuid.UUID) (*Invitation, error) {
+	var model invitationModel
+
+	if err := s.DB(ctx).First(&model, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrInvitationNotFound
+		default:
+			return nil, fmt.Errorf("query invitation: %w", err)
+		}
+	}
+
+	inv := toInvitationEntity(model)
+	return &inv, nil
+}
+
+// AcceptInvitation only marks an invitation not accepted yet, so two
+// concurrent requests with the same token cannot both succeed.
+func (s *GormStore) AcceptInvitation(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := s.DB(ctx).
+		Model(&invitationModel{}).
+		Where("id = ? AND accepted_at IS NULL", id).
+		Update("accepted_at", at)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("accept invitation: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *GormStore) DeletePendingInvitations(ctx context.Context, email string, invitedBy uuid.UUID) error {
+	err := s.DB(ctx).
+		Where("accepted_at IS NULL AND (email = ? OR invited_by = ?)", email, invitedBy).
+		Delete(&invitationModel{}).Error
+
+	if err != nil {
+		return fmt.Errorf("delete pending invitations: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) InsertPasswordReset(ctx context.Context, r PasswordReset) (*PasswordReset, error) {
+	model := passwordResetModel{
+		ID:        r.ID,
+		UserID:    r.UserID,
+		ExpiresAt: r.ExpiresAt,
+		CreatedAt: r.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store password reset: %w", err)
+	}
+
+	r = toPasswordResetEntity(model)
+	return &r, nil
+}
+
+func (s *GormStore) FindPasswordReset(ctx context.Context, id uuid.UUID) (*PasswordReset, error) {
+	var model passwordResetModel
+
+	if err := s.DB(ctx).First(&model, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrPasswordResetNotFound
+		default:
+			return nil, fmt.Errorf("query password reset: %w", err)
+		}
+	}
+
+	r := toPasswordResetEntity(model)
+	return &r, nil
+}
+
+func (s *GormStore) LatestPasswordReset(ctx context.Context, userID uuid.UUID) (*PasswordReset, error) {
+	var model passwordResetModel
+
+	err := s.DB(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Take(&model).Error
+
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrPasswordResetNotFound
+		default:
+			return nil, fmt.Errorf("query latest password reset: %w", err)
+		}
+	}
+
+	r := toPasswordResetEntity(model)
+	return &r, nil
+}
+
+// UsePasswordReset only marks a reset not used yet, so two concurrent
+// requests with the same token cannot both succeed.
+func (s *GormStore) UsePasswordReset(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := s.DB(ctx).
+		Model(&passwordResetModel{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("use password reset: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *GormStore) DeletePasswordResets(ctx context.Context, userID uuid.UUID) error {
+	if err := s.DB(ctx).Delete(&passwordResetModel{}, "user_id = ?", userID).Error; err != nil {
+		return fmt.Errorf("delete password resets: %w", err)
+	}
+	return nil
+}
+
+func (s *GormStore) FindTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactor, error) {
+	var model twoFactorModel
+
+	if err := s.DB(ctx).First(&model, "user_id = ?", userID).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrTwoFactorNotFound
+		default:
+			return nil, fmt.Errorf("query two-factor: %w", err)
+		}
+	}
+
+	tf := toTwoFactorEntity(model)
+	return &tf, nil
+}
+
+func (s *GormStore) SaveTwoFactor(ctx context.Context, tf TwoFactor) error {
+	model := twoFactorModel{
+		UserID:         tf.UserID,
+		Secret:         tf.Secret,
+		ConfirmedAt:    tf.ConfirmedAt,
+		LastStep:       tf.LastStep,
+		FailedAttempts: tf.FailedAttempts,
+		LockedUntil:    tf.LockedUntil,
+		CreatedAt:      tf.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Save(&model).Error; err != nil {
+		return fmt.Errorf("store two-factor: %w", err)
+	}
+
+	return nil
+}
+
+// UseTwoFactorStep only moves the step forward, so a code cannot be used
+// twice, even by concurrent requests.
+func (s *GormStore) UseTwoFactorStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	res := s.DB(ctx).
+		Model(&twoFactorModel{}).
+		Where("user_id = ? AND last_step < ?", userID, step).
+		Update("last_step", step)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("use two-factor step: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *GormStore) DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	return s.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&recoveryCodeModel{}, "user_id = ?", userID).Error; err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		if err := tx.Delete(&twoFactorModel{}, "user_id = ?", userID).Error; err != nil {
+			return fmt.Errorf("delete two-factor: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *GormStore) ListRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]RecoveryCode, error) {
+	var models []recoveryCodeModel
+
+	err := s.DB(ctx).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Find(&models).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("query recovery codes: %w", err)
+	}
+
+	codes := make([]RecoveryCode, 0, len(models))
+	for _, m := range models {
+		codes = append(codes, RecoveryCode{
+			ID:     m.ID,
+			UserID: m.UserID,
+			Hash:   m.Hash,
+			UsedAt: m.UsedAt,
+		})
+	}
+
+	return codes, nil
+}
+
+func (s *GormStore) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []RecoveryCode) error {
+	models := make([]recoveryCodeModel, 0, len(codes))
+	for _, c := range codes {
+		models = append(models, recoveryCodeModel{
+			ID:     c.ID,
+			UserID: userID,
+			Hash:   c.Hash,
+		})
+	}
+
+	return s.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&recoveryCodeModel{}, "user_id = ?", userID).Error; err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		if len(models) == 0 {
+			return nil
+		}
+		if err := tx.Create(&models).Error; err != nil {
+			return fmt.Errorf("store recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *GormStore) UseRecoveryCode(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := s.DB(ctx).
+		Model(&recoveryCodeModel{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("use recovery code: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *GormStore) FindTwoFactorForUpdate(ctx context.Context, userID uuid.UUID) (*TwoFactor, error) {
+	var model twoFactorModel
+
+	err := s.DB(ctx).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		First(&model, "user_id = ?", userID).Error
+
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrTwoFactorNotFound
+		default:
+			return nil, fmt.Errorf("query two-factor for update: %w", err)
+		}
+	}
+
+	tf := toTwoFactorEntity(model)
+	return &tf, nil
+}
+
+func (s *GormStore) UpdateTwoFactorFailures(ctx context.Context, userID uuid.UUID, attempts int, lockedUntil *time.Time) error {
+	err := s.DB(ctx).
+		Model(&twoFactorModel{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"failed_attempts": attempts,
+			"locked_until":    lockedUntil,
+		}).Error
+
+	if err != nil {
+		return fmt.Errorf("update two-factor failures: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GormStore) InsertLoginChallenge(ctx context.Context, ch LoginChallenge) (*LoginChallenge, error) {
+	model := loginChallengeModel{
+		ID:        ch.ID,
+		UserID:    ch.UserID,
+		ExpiresAt: ch.ExpiresAt,
+		CreatedAt: ch.CreatedAt,
+	}
+
+	if err := s.DB(ctx).Create(&model).Error; err != nil {
+		return nil, fmt.Errorf("store login challenge: %w", err)
+	}
+
+	ch = toLoginChallengeEntity(model)
+	return &ch, nil
+}
+
+func (s *GormStore) FindLoginChallenge(ctx context.Context, id uuid.UUID) (*LoginChallenge, error) {
+	var model loginChallengeModel
+
+	if err := s.DB(ctx).First(&model, "id = ?", id).Error; err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrLoginChallengeNotFound
+		default:
+			return nil, fmt.Errorf("query login challenge: %w", err)
+		}
+	}
+
+	ch := toLoginChallengeEntity(model)
+	return &ch, nil
+}
+
+// UseLoginChallenge only marks a challenge not used yet, so two concurrent
+// requests with the same token cannot both succeed.
+func (s *GormStore) UseLoginChallenge(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := s.DB(ctx).
+		Model(&loginChallengeModel{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+
+	if res.Error != nil {
+		return false, fmt.Errorf("use login challenge: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *GormStore) UseLoginChallenges(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	err := s.DB(ctx).
+		Model(&loginChallengeModel{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+
+	if err != nil {
+		return fmt.Errorf("use login challenges: %w", err)
+	}
+
+	return nil
+}
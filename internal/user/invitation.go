@@ -0,0 +1,166 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/event"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/signing"
+)
+
+var (
+	ErrInvitationTokenInvalid  = errors.New("invitation token is invalid")
+	ErrInvitationTokenExpired  = errors.New("invitation token is expired")
+	ErrInvitationTokenAccepted = errors.New("invitation already accepted")
+)
+
+const defaultInviteTTL = 72 * time.Hour
+
+// Invitation lets the holder of its emailed token create an account for
+// the invited email address.
+type Invitation struct {
+	ID         uuid.UUID
+	Email      string
+	InvitedBy  uuid.UUID
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type Inviter struct {
+	store  Store
+	svc    *Service
+	txm    db.TxManager
+	event  event.Publisher
+	signer *signing.Signer
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+func NewInviter(
+	cfg *config.Config,
+	store Store,
+	svc *Service,
+	txm db.TxManager,
+	evt event.Publisher,
+	log logger.Logger,
+) (*Inviter, error) {
+	signer, err := signing.NewSigner([]byte(cfg.AppKey), "user-invitation")
+	if err != nil {
+		return nil, fmt.Errorf("user invitation signer: %w", err)
+	}
+
+	ttl := cfg.UserInviteTTL
+	if ttl <= 0 {
+		ttl = defaultInviteTTL
+	}
+
+	return &Inviter{
+		store:  store,
+		svc:    svc,
+		txm:    txm,
+		event:  evt,
+		signer: signer,
+		ttl:    ttl,
+		logger: log.With("component", "service.user.inviter"),
+	}, nil
+}
+
+// Invite records an invitation for the email and publishes Invited, whose
+// handler emails the token.
+func (i *Inviter) Invite(ctx context.Context, email string, invitedBy uuid.UUID) (*Invitation, error) {
+	exists, err := i.store.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("exists by email: %w", err)
+	}
+
+	if exists {
+		i.logger.WarnContext(ctx, "invited email already taken", "email", email)
+		return nil, ErrEmailAlreadyTaken
+	}
+
+	now := time.Now()
+
+	inv, err := i.store.InsertInvitation(ctx, Invitation{
+		ID:        uuid.New(),
+		Email:     email,
+		InvitedBy: invitedBy,
+		ExpiresAt: now.Add(i.ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert invitation: %w", err)
+	}
+
+	if err = i.event.Publish(ctx, Invited{ID: inv.ID}); err != nil {
+		return nil, fmt.Errorf("publish event: %w", err)
+	}
+
+	return inv, nil
+}
+
+func (i *Inviter) Get(ctx context.Context, id uuid.UUID) (*Invitation, error) {
+	inv, err := i.store.FindInvitation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// Token returns the signed token accepting the invitation.
+func (i *Inviter) Token(inv *Invitation) string {
+	return i.signer.Sign(inv.ID, inv.ExpiresAt)
+}
+
+// Accept consumes the token and creates the invited user.
+func (i *Inviter) Accept(ctx context.Context, token, name, password string) (*User, error) {
+	id, exp, err := i.signer.Parse(token)
+	if err != nil {
+		return nil, ErrInvitationTokenInvalid
+	}
+
+	if time.Now().After(exp) {
+		return nil, ErrInvitationTokenExpired
+	}
+
+	var u *User
+
+	txErr := i.txm.Do(ctx, func(txCtx context.Context) error {
+		inv, err := i.store.FindInvitation(txCtx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvitationNotFound):
+				return ErrInvitationTokenInvalid
+			default:
+				return fmt.Errorf("find invitation: %w", err)
+			}
+		}
+
+		accepted, err := i.store.AcceptInvitation(txCtx, inv.ID, time.Now())
+		if err != nil {
+			return fmt.Errorf("accept invitation: %w", err)
+		}
+		if !accepted {
+			return ErrInvitationTokenAccepted
+		}
+
+		if u, err = i.svc.Create(txCtx, name, inv.Email, password); err != nil {
+			return fmt.Errorf("create user: %w", err)
+		}
+
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, fmt.Errorf("accept invitation tx: %w", txErr)
+	}
+
+	return u, nil
+}
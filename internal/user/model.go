@@ -1,14 +1,31 @@
 package user
 
-import "github.com/google/uuid"
+import (
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/rbac"
+)
 
 type User struct {
 	ID       uuid.UUID
 	Name     string
 	Email    string
 	Password string
+	// Timezone is the IANA zone reports are shown in; empty for the app default.
+	Timezone string
+
+	// Roles and Permissions are loaded for authenticated users.
+	Roles       []string          `gorm:"-"`
+	Permissions []rbac.Permission `gorm:"-"`
 }
 
 func (u User) UserID() any {
 	return u.ID
 }
+
+// Can reports whether the user was granted the permission.
+func (u User) Can(p rbac.Permission) bool {
+	return slices.Contains(u.Permissions, p)
+}
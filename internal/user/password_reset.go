@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/event"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/optional"
+	"github.com/gelozr/go-dash/internal/signing"
+)
+
+var (
+	ErrResetTokenInvalid = errors.New("password reset token is invalid")
+	ErrResetTokenExpired = errors.New("password reset token is expired")
+	ErrResetTokenUsed    = errors.New("password reset token is used")
+)
+
+const (
+	defaultPasswordResetTTL = time.Hour
+	passwordResetInterval   = time.Minute
+)
+
+type PasswordReset struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Resetter lets users who forgot their password set a new one through a
+// single-use link emailed to them.
+type Resetter struct {
+	store  Store
+	svc    *Service
+	txm    db.TxManager
+	event  event.Publisher
+	signer *signing.Signer
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+func NewResetter(
+	cfg *config.Config,
+	store Store,
+	svc *Service,
+	txm db.TxManager,
+	evt event.Publisher,
+	log logger.Logger,
+) (*Resetter, error) {
+	signer, err := signing.NewSigner([]byte(cfg.AppKey), "password-reset")
+	if err != nil {
+		return nil, fmt.Errorf("password reset signer: %w", err)
+	}
+
+	ttl := cfg.PasswordResetTTL
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+
+	return &Resetter{
+		store:  store,
+		svc:    svc,
+		txm:    txm,
+		event:  evt,
+		signer: signer,
+		ttl:    ttl,
+		logger: log.With("component", "service.user.resetter"),
+	}, nil
+}
+
+// Request records a reset for the user with the email and publishes
+// PasswordResetRequested, whose handler emails the token. Unknown emails and
+// repeated requests are only logged, so callers cannot tell them apart.
+func (r *Resetter) Request(ctx context.Context, email string) error {
+	u, err := r.store.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			r.logger.InfoContext(ctx, "password reset for unknown email")
+			return nil
+		}
+		return fmt.Errorf("find user by email: %w", err)
+	}
+
+	last, err := r.store.LatestPasswordReset(ctx, u.ID)
+	if err != nil && !errors.Is(err, ErrPasswordResetNotFound) {
+		return fmt.Errorf("latest password reset: %w", err)
+	}
+
+	if last != nil && time.Since(last.CreatedAt) < passwordResetInterval {
+		r.logger.WarnContext(ctx, "password reset throttled", "user_id", u.ID)
+		return nil
+	}
+
+	now := time.Now()
+
+	reset, err := r.store.InsertPasswordReset(ctx, PasswordReset{
+		ID:        uuid.New(),
+		UserID:    u.ID,
+		ExpiresAt: now.Add(r.ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("insert password reset: %w", err)
+	}
+
+	if err = r.event.Publish(ctx, PasswordResetRequested{ID: reset.ID}); err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Resetter) Get(ctx context.Context, id uuid.UUID) (*PasswordReset, error) {
+	reset, err := r.store.FindPasswordReset(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find password reset: %w", err)
+	}
+	return reset, nil
+}
+
+// Token returns the signed token of the reset.
+func (r *Resetter) Token(reset *PasswordReset) string {
+	return r.signer.Sign(reset.ID, reset.ExpiresAt)
+}
+
+// Reset consumes the token and sets the new password of its user, whose ID
+// is returned.
+func (r *Resetter) Reset(ctx context.Context, token, password string) (uuid.UUID, error) {
+	id, exp, err := r.signer.Parse(token)
+	if err != nil {
+		return uuid.Nil, ErrResetTokenInvalid
+	}
+
+	if time.Now().After(exp) {
+		return uuid.Nil, ErrResetTokenExpired
+	}
+
+	var userID uuid.UUID
+
+	txErr := r.txm.Do(ctx, func(txCtx context.Context) error {
+		reset, err := r.store.FindPasswordReset(txCtx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrPasswordResetNotFound):
+				return ErrResetTokenInvalid
+			default:
+				return fmt.Errorf("find password reset: %w", err)
+			}
+		}
+
+		used, err := r.store.UsePasswordReset(txCtx, reset.ID, time.Now())
+		if err != nil {
+			return fmt.Errorf("use password reset: %w", err)
+		}
+		if !used {
+			return ErrResetTokenUsed
+		}
+
+		if _, err = r.svc.Update(txCtx, reset.UserID, UpdateInput{Password: optional.Of(password)}); err != nil {
+			return fmt.Errorf("update password: %w", err)
+		}
+
+		userID = reset.UserID
+		return nil
+	})
+
+	if txErr != nil {
+		return uuid.Nil, fmt.Errorf("reset password tx: %w", txErr)
+	}
+
+	return userID, nil
+}
@@ -3,22 +3,48 @@ package user
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/hashing"
+	"github.com/gelozr/go-dash/internal/listing"
 	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/optional"
 )
 
 type Service struct {
 	store  Store
+	hasher hashing.Hasher
 	logger logger.Logger
 }
 
-func NewService(store Store, log logger.Logger) *Service {
+func NewService(store Store, hasher hashing.Manager, log logger.Logger) *Service {
 	return &Service{
 		store:  store,
+		hasher: hasher,
 		logger: log.With("component", "service.user"),
 	}
 }
 
+func (s *Service) List(ctx context.Context, page listing.Page) (listing.Result[User], error) {
+	users, total, err := s.store.List(ctx, page)
+	if err != nil {
+		return listing.Result[User]{}, fmt.Errorf("list users: %w", err)
+	}
+	return listing.NewResult(users, page, total), nil
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*User, error) {
+	u, err := s.store.FindByID(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("find user: %w", err)
+	}
+
+	return u, nil
+}
+
 func (s *Service) GetByEmail(ctx context.Context, email string) (*User, error) {
 	u, err := s.store.FindByEmail(ctx, email)
 
@@ -28,3 +54,106 @@ func (s *Service) GetByEmail(ctx context.Context, email string) (*User, error) {
 
 	return u, nil
 }
+
+// SetTimezone stores the user's preferred IANA timezone; empty resets it
+// to the app default.
+func (s *Service) SetTimezone(ctx context.Context, id uuid.UUID, tz string) error {
+	if tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			return ErrInvalidTimezone
+		}
+	}
+
+	if err := s.store.UpdateTimezone(ctx, id, tz); err != nil {
+		return fmt.Errorf("update timezone: %w", err)
+	}
+
+	return nil
+}
+
+// Create stores a new user with the plaintext password hashed.
+func (s *Service) Create(ctx context.Context, name, email, password string) (*User, error) {
+	exists, err := s.store.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("exists by email: %w", err)
+	}
+
+	if exists {
+		s.logger.WarnContext(ctx, "email already taken", "email", email)
+		return nil, ErrEmailAlreadyTaken
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+
+	u, err := s.store.Insert(ctx, User{
+		ID:       uuid.New(),
+		Name:     name,
+		Email:    email,
+		Password: hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert user: %w", err)
+	}
+
+	return u, nil
+}
+
+// Update changes the fields present in req; a present Password is the
+// plaintext and is hashed before it is stored.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, req UpdateInput) (*User, error) {
+	curr, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find user: %w", err)
+	}
+
+	if req.Email.IsPresent && req.Email.Val != curr.Email {
+		exists, err := s.store.ExistsByEmail(ctx, req.Email.Val)
+		if err != nil {
+			return nil, fmt.Errorf("exists by email: %w", err)
+		}
+		if exists {
+			s.logger.WarnContext(ctx, "email already taken", "email", req.Email.Val)
+			return nil, ErrEmailAlreadyTaken
+		}
+	}
+
+	if req.Password.IsPresent {
+		hash, err := s.hasher.Hash(req.Password.Val)
+		if err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+		req.Password = optional.Of(hash)
+	}
+
+	if err = s.store.Update(ctx, id, req); err != nil {
+		return nil, fmt.Errorf("update user: %w", err)
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Delete deletes the user with their password resets and the pending
+// invitations sent to or by them, so none of their links stay usable.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	u, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("find user: %w", err)
+	}
+
+	if err = s.store.DeletePasswordResets(ctx, id); err != nil {
+		return fmt.Errorf("delete password resets: %w", err)
+	}
+
+	if err = s.store.DeletePendingInvitations(ctx, u.Email, id); err != nil {
+		return fmt.Errorf("delete pending invitations: %w", err)
+	}
+
+	if err = s.store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	return nil
+}
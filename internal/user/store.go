@@ -3,10 +3,84 @@ package user
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/listing"
+	"github.com/gelozr/go-dash/internal/optional"
 )
 
-var ErrUserNotFound = errors.New("customer not found")
+var (
+	ErrUserNotFound       = errors.New("customer not found")
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrEmailAlreadyTaken  = errors.New("email already exists")
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	ErrPasswordResetNotFound = errors.New("password reset not found")
+	ErrTwoFactorNotFound     = errors.New("two-factor not found")
+
+	ErrLoginChallengeNotFound = errors.New("login challenge not found")
+)
 
 type Store interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	List(ctx context.Context, page listing.Page) ([]User, int64, error)
+	Insert(ctx context.Context, u User) (*User, error)
+	Update(ctx context.Context, id uuid.UUID, req UpdateInput) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	UpdateTimezone(ctx context.Context, id uuid.UUID, tz string) error
+
+	InsertInvitation(ctx context.Context, inv Invitation) (*Invitation, error)
+	FindInvitation(ctx context.Context, id uuid.UUID) (*Invitation, error)
+	// AcceptInvitation marks the invitation accepted, reporting false if it
+	// already was.
+	AcceptInvitation(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// DeletePendingInvitations deletes the invitations not yet accepted that
+	// were sent to the email or by the user.
+	DeletePendingInvitations(ctx context.Context, email string, invitedBy uuid.UUID) error
+
+	InsertPasswordReset(ctx context.Context, r PasswordReset) (*PasswordReset, error)
+	FindPasswordReset(ctx context.Context, id uuid.UUID) (*PasswordReset, error)
+	LatestPasswordReset(ctx context.Context, userID uuid.UUID) (*PasswordReset, error)
+	// UsePasswordReset marks the reset used, reporting false if it already
+	// was.
+	UsePasswordReset(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	DeletePasswordResets(ctx context.Context, userID uuid.UUID) error
+
+	FindTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactor, error)
+	// FindTwoFactorForUpdate finds the user's two-factor and locks its row
+	// until the transaction of ctx ends.
+	FindTwoFactorForUpdate(ctx context.Context, userID uuid.UUID) (*TwoFactor, error)
+	UpdateTwoFactorFailures(ctx context.Context, userID uuid.UUID, attempts int, lockedUntil *time.Time) error
+	// SaveTwoFactor inserts or replaces the user's two-factor.
+	SaveTwoFactor(ctx context.Context, tf TwoFactor) error
+	// UseTwoFactorStep records the step of an accepted code, reporting false
+	// if it is not later than the last one.
+	UseTwoFactorStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	// DeleteTwoFactor deletes the user's two-factor and recovery codes.
+	DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error
+	// ListRecoveryCodes returns the unused recovery codes of the user.
+	ListRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]RecoveryCode, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []RecoveryCode) error
+	// UseRecoveryCode marks the code used, reporting false if it already
+	// was.
+	UseRecoveryCode(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+
+	InsertLoginChallenge(ctx context.Context, ch LoginChallenge) (*LoginChallenge, error)
+	FindLoginChallenge(ctx context.Context, id uuid.UUID) (*LoginChallenge, error)
+	// UseLoginChallenge marks the challenge used, reporting false if it
+	// already was.
+	UseLoginChallenge(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// UseLoginChallenges marks every pending challenge of the user used.
+	UseLoginChallenges(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
+
+// UpdateInput changes the fields that are present; Password is the hash.
+type UpdateInput struct {
+	Name     optional.Optional[string]
+	Email    optional.Optional[string]
+	Password optional.Optional[string]
 }
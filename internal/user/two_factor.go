@@ -0,0 +1,459 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gelozr/go-dash/internal/config"
+	"github.com/gelozr/go-dash/internal/db"
+	"github.com/gelozr/go-dash/internal/hashing"
+	"github.com/gelozr/go-dash/internal/logger"
+	"github.com/gelozr/go-dash/internal/signing"
+	"github.com/gelozr/go-dash/internal/totp"
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorCodeInvalid = errors.New("two-factor code is invalid")
+	ErrTwoFactorLocked      = errors.New("two-factor login is locked after failed codes")
+
+	ErrChallengeInvalid = errors.New("login challenge is invalid")
+	ErrChallengeExpired = errors.New("login challenge is expired")
+)
+
+const (
+	challengeTTL      = 5 * time.Minute
+	maxFailedCodes    = 5
+	twoFactorLockout  = 15 * time.Minute
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 10
+	defaultTOTPIssuer = "go-dash"
+)
+
+// TwoFactor is the TOTP secret of a user. It is pending until the user
+// confirms it with a code, and only then required to log in.
+type TwoFactor struct {
+	UserID      uuid.UUID
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastStep is the TOTP time step of the last accepted code; codes of it
+	// and earlier steps are refused.
+	LastStep int64
+	// FailedAttempts counts wrong login codes since the last good one; at
+	// maxFailedCodes the login is locked until LockedUntil.
+	FailedAttempts int
+	LockedUntil    *time.Time
+	CreatedAt      time.Time
+}
+
+func (t TwoFactor) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+func (t TwoFactor) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// LoginChallenge is a login whose password was checked, waiting for a
+// code. It completes once.
+type LoginChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RecoveryCode stands in for a TOTP code once, for users who lost their
+// authenticator.
+type RecoveryCode struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Hash   string
+	UsedAt *time.Time
+}
+
+// TwoFactorAuth enrolls users in TOTP two-factor authentication and checks
+// their codes at login.
+type TwoFactorAuth struct {
+	store  Store
+	hasher hashing.Manager
+	txm    db.TxManager
+	signer *signing.Signer
+	issuer string
+	logger logger.Logger
+}
+
+func NewTwoFactorAuth(
+	cfg *config.Config,
+	store Store,
+	hasher hashing.Manager,
+	txm db.TxManager,
+	log logger.Logger,
+) (*TwoFactorAuth, error) {
+	signer, err := signing.NewSigner([]byte(cfg.AppKey), "login-challenge")
+	if err != nil {
+		return nil, fmt.Errorf("login challenge signer: %w", err)
+	}
+
+	issuer := cfg.TOTPIssuer
+	if issuer == "" {
+		issuer = defaultTOTPIssuer
+	}
+
+	return &TwoFactorAuth{
+		store:  store,
+		hasher: hasher,
+		txm:    txm,
+		signer: signer,
+		issuer: issuer,
+		logger: log.With("component", "service.user.two_factor"),
+	}, nil
+}
+
+// Enabled reports whether the user confirmed two-factor authentication.
+func (a *TwoFactorAuth) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	tf, err := a.store.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("find two-factor: %w", err)
+	}
+	return tf.Confirmed(), nil
+}
+
+// Enroll starts enrollment with a new secret, replacing a pending one, and
+// returns the secret with its otpauth:// URI.
+func (a *TwoFactorAuth) Enroll(ctx context.Context, u *User) (string, string, error) {
+	enabled, err := a.Enabled(ctx, u.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("new secret: %w", err)
+	}
+
+	err = a.store.SaveTwoFactor(ctx, TwoFactor{
+		UserID:    u.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("save two-factor: %w", err)
+	}
+
+	return secret, totp.URI(a.issuer, u.Email, secret), nil
+}
+
+// Confirm enables two-factor authentication with a code of the pending
+// secret and returns the recovery codes, which are only stored hashed.
+func (a *TwoFactorAuth) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	tf, err := a.store.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotFound) {
+			return nil, ErrTwoFactorNotEnrolled
+		}
+		return nil, fmt.Errorf("find two-factor: %w", err)
+	}
+	if tf.Confirmed() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	now := time.Now()
+
+	step, ok := totp.Validate(tf.Secret, code, now)
+	if !ok {
+		return nil, ErrTwoFactorCodeInvalid
+	}
+
+	codes, recovery, err := a.newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tf.ConfirmedAt = &now
+	tf.LastStep = step
+
+	txErr := a.txm.Do(ctx, func(txCtx context.Context) error {
+		if err := a.store.SaveTwoFactor(txCtx, *tf); err != nil {
+			return fmt.Errorf("save two-factor: %w", err)
+		}
+		if err := a.store.ReplaceRecoveryCodes(txCtx, userID, recovery); err != nil {
+			return fmt.Errorf("store recovery codes: %w", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return nil, fmt.Errorf("confirm two-factor tx: %w", txErr)
+	}
+
+	a.logger.InfoContext(ctx, "two-factor enabled", "user_id", userID)
+
+	return codes, nil
+}
+
+// Verify checks a TOTP or recovery code of the user, each usable once.
+func (a *TwoFactorAuth) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	tf, err := a.store.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotFound) {
+			return ErrTwoFactorNotEnrolled
+		}
+		return fmt.Errorf("find two-factor: %w", err)
+	}
+
+	return a.verify(ctx, tf, code)
+}
+
+func (a *TwoFactorAuth) verify(ctx context.Context, tf *TwoFactor, code string) error {
+	if !tf.Confirmed() {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+
+	if step, ok := totp.Validate(tf.Secret, code, time.Now()); ok {
+		used, err := a.store.UseTwoFactorStep(ctx, tf.UserID, step)
+		if err != nil {
+			return fmt.Errorf("use two-factor step: %w", err)
+		}
+		if !used {
+			return ErrTwoFactorCodeInvalid
+		}
+		return nil
+	}
+
+	return a.useRecoveryCode(ctx, tf.UserID, code)
+}
+
+// Disable turns two-factor authentication off, given a valid code.
+func (a *TwoFactorAuth) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := a.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := a.Remove(ctx, userID); err != nil {
+		return err
+	}
+
+	a.logger.InfoContext(ctx, "two-factor disabled", "user_id", userID)
+
+	return nil
+}
+
+// Remove deletes the user's secret and recovery codes, without a code.
+func (a *TwoFactorAuth) Remove(ctx context.Context, userID uuid.UUID) error {
+	if err := a.store.DeleteTwoFactor(ctx, userID); err != nil {
+		return fmt.Errorf("delete two-factor: %w", err)
+	}
+	return nil
+}
+
+// Challenge records a login of the user, whose password was checked, and
+// returns the token completing it together with a code.
+func (a *TwoFactorAuth) Challenge(ctx context.Context, userID uuid.UUID) (string, time.Duration, error) {
+	now := time.Now()
+
+	ch, err := a.store.InsertLoginChallenge(ctx, LoginChallenge{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ExpiresAt: now.Add(challengeTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("insert login challenge: %w", err)
+	}
+
+	return a.signer.Sign(ch.ID, ch.ExpiresAt), challengeTTL, nil
+}
+
+// Complete consumes the challenge of the token with a TOTP or recovery code
+// and returns its user. Wrong codes count against the user, whichever
+// challenge they come with; after maxFailedCodes the user's challenges are
+// invalidated and logins fail with ErrTwoFactorLocked for a while.
+func (a *TwoFactorAuth) Complete(ctx context.Context, token, code string) (uuid.UUID, error) {
+	id, exp, err := a.signer.Parse(token)
+	if err != nil {
+		return uuid.Nil, ErrChallengeInvalid
+	}
+
+	if time.Now().After(exp) {
+		return uuid.Nil, ErrChallengeExpired
+	}
+
+	var (
+		userID uuid.UUID
+		// a wrong code is recorded, so it is reported after the commit
+		failed error
+	)
+
+	txErr := a.txm.Do(ctx, func(txCtx context.Context) error {
+		ch, err := a.store.FindLoginChallenge(txCtx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrLoginChallengeNotFound):
+				return ErrChallengeInvalid
+			default:
+				return fmt.Errorf("find login challenge: %w", err)
+			}
+		}
+		if ch.UsedAt != nil {
+			return ErrChallengeInvalid
+		}
+
+		// serializes the attempts of the user
+		tf, err := a.store.FindTwoFactorForUpdate(txCtx, ch.UserID)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrTwoFactorNotFound):
+				return ErrTwoFactorNotEnrolled
+			default:
+				return fmt.Errorf("find two-factor: %w", err)
+			}
+		}
+
+		now := time.Now()
+
+		if tf.Locked(now) {
+			return ErrTwoFactorLocked
+		}
+
+		if err := a.verify(txCtx, tf, code); err != nil {
+			if !errors.Is(err, ErrTwoFactorCodeInvalid) {
+				return err
+			}
+			locked, err := a.fail(txCtx, tf, now)
+			failed = ErrTwoFactorCodeInvalid
+			if locked {
+				failed = ErrTwoFactorLocked
+			}
+			return err
+		}
+
+		used, err := a.store.UseLoginChallenge(txCtx, ch.ID, now)
+		if err != nil {
+			return fmt.Errorf("use login challenge: %w", err)
+		}
+		if !used {
+			return ErrChallengeInvalid
+		}
+
+		if err := a.store.UpdateTwoFactorFailures(txCtx, tf.UserID, 0, nil); err != nil {
+			return fmt.Errorf("reset failed codes: %w", err)
+		}
+
+		userID = ch.UserID
+		return nil
+	})
+
+	if txErr != nil {
+		return uuid.Nil, fmt.Errorf("complete login challenge tx: %w", txErr)
+	}
+	if failed != nil {
+		return uuid.Nil, failed
+	}
+
+	return userID, nil
+}
+
+// fail records a wrong login code, reporting whether it locked the user's
+// two-factor login.
+func (a *TwoFactorAuth) fail(ctx context.Context, tf *TwoFactor, now time.Time) (bool, error) {
+	attempts := tf.FailedAttempts + 1
+
+	if attempts < maxFailedCodes {
+		if err := a.store.UpdateTwoFactorFailures(ctx, tf.UserID, attempts, nil); err != nil {
+			return false, fmt.Errorf("record failed code: %w", err)
+		}
+		return false, nil
+	}
+
+	until := now.Add(twoFactorLockout)
+	if err := a.store.UpdateTwoFactorFailures(ctx, tf.UserID, 0, &until); err != nil {
+		return false, fmt.Errorf("lock two-factor: %w", err)
+	}
+
+	if err := a.store.UseLoginChallenges(ctx, tf.UserID, now); err != nil {
+		return false, fmt.Errorf("invalidate login challenges: %w", err)
+	}
+
+	a.logger.WarnContext(ctx, "two-factor locked after failed codes", "user_id", tf.UserID)
+
+	return true, nil
+}
+
+func (a *TwoFactorAuth) useRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	// spares hashing against every code for mistyped TOTP codes
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLen {
+		return ErrTwoFactorCodeInvalid
+	}
+
+	recovery, err := a.store.ListRecoveryCodes(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list recovery codes: %w", err)
+	}
+
+	for _, rc := range recovery {
+		ok, err := a.hasher.Check(code, rc.Hash)
+		if err != nil {
+			return fmt.Errorf("check recovery code: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		used, err := a.store.UseRecoveryCode(ctx, rc.ID, time.Now())
+		if err != nil {
+			return fmt.Errorf("use recovery code: %w", err)
+		}
+		if !used {
+			return ErrTwoFactorCodeInvalid
+		}
+
+		a.logger.InfoContext(ctx, "recovery code used", "user_id", userID)
+		return nil
+	}
+
+	return ErrTwoFactorCodeInvalid
+}
+
+// newRecoveryCodes returns recovery codes as shown to the user, formatted
+// xxxxx-xxxxx, and as stored.
+func (a *TwoFactorAuth) newRecoveryCodes(userID uuid.UUID) ([]string, []RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	recovery := make([]RecoveryCode, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		// 5 random bits a character
+		c := strings.ToLower(rand.Text()[:recoveryCodeLen])
+
+		hash, err := a.hasher.Hash(c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("hash recovery code: %w", err)
+		}
+
+		codes = append(codes, c[:5]+"-"+c[5:])
+		recovery = append(recovery, RecoveryCode{ID: uuid.New(), UserID: userID, Hash: hash})
+	}
+
+	return codes, recovery, nil
+}
+
+// normalizeRecoveryCode drops the dash and case users may type differently.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}